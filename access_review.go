@@ -0,0 +1,412 @@
+package aiven
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// AccessViaDirect is a direct project membership.
+	AccessViaDirect = "direct"
+	// AccessViaInvitation is a pending project invitation.
+	AccessViaInvitation = "invitation"
+	// AccessViaAccountTeam is an account team associated with the project.
+	AccessViaAccountTeam = "account_team"
+	// AccessViaUserGroup is an organization user group granted access to the project.
+	AccessViaUserGroup = "user_group"
+)
+
+// accessReviewCSVHeader is the header row of AccessReview.WriteCSV.
+var accessReviewCSVHeader = []string{
+	"user_id", "user_email", "project", "role", "via", "via_id", "via_name",
+	"pending", "since", "last_activity_time", "stale_invitation", "inactive", "activity_unknown",
+}
+
+type (
+	// AccessReviewOptions configures the access review.
+	AccessReviewOptions struct {
+		// StaleInvitationAge flags pending invitations older than this, disabled when zero.
+		StaleInvitationAge time.Duration
+		// InactiveDays flags users whose last activity is older than this many days, disabled when zero.
+		InactiveDays int
+		// Concurrency is the number of projects reviewed concurrently, defaults to 4.
+		Concurrency int
+	}
+
+	// AccessReviewRow is an effective permission: a user reaching a project with a role via a membership.
+	AccessReviewRow struct {
+		UserID    string `json:"user_id,omitempty"`
+		UserEmail string `json:"user_email"`
+		Project   string `json:"project"`
+		Role      string `json:"role"`
+		Via       string `json:"via"`
+		// ViaID and ViaName identify the team or the user group the access is granted through.
+		ViaID   string `json:"via_id,omitempty"`
+		ViaName string `json:"via_name,omitempty"`
+		// Pending is set for invitations which are not accepted yet.
+		Pending          bool       `json:"pending"`
+		Since            *time.Time `json:"since,omitempty"`
+		LastActivityTime *time.Time `json:"last_activity_time,omitempty"`
+		StaleInvitation  bool       `json:"stale_invitation"`
+		// Inactive is set when the last activity of the user is known and older than InactiveDays.
+		Inactive bool `json:"inactive"`
+		// ActivityUnknown is set when the last activity of the user is not known,
+		// the user is then reported neither active nor inactive.
+		ActivityUnknown bool `json:"activity_unknown"`
+	}
+
+	// AccessReview is the flattened list of effective permissions of an organization.
+	AccessReview struct {
+		OrganizationID string            `json:"organization_id"`
+		ReviewTime     time.Time         `json:"review_time"`
+		Rows           []AccessReviewRow `json:"rows"`
+	}
+
+	// accessReviewTeam is an account team with its members and projects.
+	accessReviewTeam struct {
+		team     AccountTeam
+		members  []AccountTeamMember
+		projects []AccountTeamProject
+	}
+
+	// accessReviewGroup is an organization user group with its members.
+	accessReviewGroup struct {
+		group   OrganizationUserGroupResponse
+		members []OrganizationUserGroupMember
+	}
+
+	// accessReviewProject holds the memberships of a project.
+	accessReviewProject struct {
+		name        string
+		users       []*ProjectUser
+		invitations []*ProjectInvitation
+		groups      []*ProjectUserGroup
+	}
+
+	// accessReviewData is everything the access review is built from.
+	accessReviewData struct {
+		users    []OrganizationMemberInfo
+		teams    []accessReviewTeam
+		groups   []accessReviewGroup
+		projects []accessReviewProject
+	}
+)
+
+// AccessReview resolves who can reach which project of the organization with what role,
+// through direct memberships, pending invitations, account teams and organization user groups.
+func (c *Client) AccessReview(ctx context.Context, organizationID string, opts AccessReviewOptions) (*AccessReview, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultInventoryConcurrency
+	}
+
+	data, err := c.loadAccessReviewData(ctx, organizationID, opts.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildAccessReview(organizationID, data, opts, time.Now().UTC()), nil
+}
+
+func (c *Client) loadAccessReviewData(ctx context.Context, organizationID string, concurrency int) (*accessReviewData, error) {
+	org, err := c.Organization.Get(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := new(accessReviewData)
+
+	users, err := c.OrganizationUser.List(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	data.users = users.Users
+
+	if org.AccountID != "" {
+		teams, err := c.AccountTeams.List(ctx, org.AccountID)
+		if err != nil {
+			return nil, err
+		}
+
+		data.teams = make([]accessReviewTeam, len(teams.Teams))
+		err = forEachConcurrently(ctx, len(teams.Teams), concurrency, func(ctx context.Context, i int) error {
+			t := accessReviewTeam{team: teams.Teams[i]}
+
+			members, err := c.AccountTeamMembers.List(ctx, org.AccountID, t.team.Id)
+			if err != nil {
+				return err
+			}
+			t.members = members.Members
+
+			projects, err := c.AccountTeamProjects.List(ctx, org.AccountID, t.team.Id)
+			if err != nil {
+				return err
+			}
+			t.projects = projects.Projects
+
+			data.teams[i] = t
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groups, err := c.OrganizationUserGroups.List(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	data.groups = make([]accessReviewGroup, len(groups.UserGroups))
+	err = forEachConcurrently(ctx, len(groups.UserGroups), concurrency, func(ctx context.Context, i int) error {
+		g := groups.UserGroups[i]
+		members, err := c.OrganizationUserGroupMembers.List(ctx, organizationID, g.UserGroupID)
+		if err != nil {
+			return err
+		}
+
+		data.groups[i] = accessReviewGroup{group: g, members: members.Members}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := c.ProjectOrganization.OrganizationProjects(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	data.projects = make([]accessReviewProject, len(projects))
+	err = forEachConcurrently(ctx, len(projects), concurrency, func(ctx context.Context, i int) error {
+		p := accessReviewProject{name: projects[i].Name}
+
+		users, invitations, err := c.ProjectUsers.List(ctx, p.name)
+		if err != nil {
+			return err
+		}
+		p.users, p.invitations = users, invitations
+
+		if p.groups, err = c.ProjectOrganization.List(ctx, p.name); err != nil {
+			return err
+		}
+
+		data.projects[i] = p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// buildAccessReview flattens the memberships into rows, sorted by project, user and via.
+func buildAccessReview(organizationID string, data *accessReviewData, opts AccessReviewOptions, now time.Time) *AccessReview {
+	// The last activity is reported for the organization users and the user group members, the latest wins
+	lastActivity := make(map[string]*time.Time)
+	setLastActivity := func(userID string, t *time.Time) {
+		if t != nil && (lastActivity[userID] == nil || t.After(*lastActivity[userID])) {
+			lastActivity[userID] = t
+		}
+	}
+
+	userIDs := make(map[string]string, len(data.users))
+	for _, u := range data.users {
+		userIDs[u.UserInfo.UserEmail] = u.UserID
+		setLastActivity(u.UserID, u.LastActivityTime)
+	}
+
+	groups := make(map[string]accessReviewGroup, len(data.groups))
+	for _, g := range data.groups {
+		groups[g.group.UserGroupID] = g
+		for _, m := range g.members {
+			setLastActivity(m.UserID, m.LastActivityTime)
+		}
+	}
+
+	review := &AccessReview{OrganizationID: organizationID, ReviewTime: now}
+	add := func(row AccessReviewRow) {
+		if row.UserID == "" {
+			row.UserID = userIDs[row.UserEmail]
+		}
+		row.LastActivityTime = lastActivity[row.UserID]
+		row.ActivityUnknown = row.LastActivityTime == nil
+		if opts.InactiveDays > 0 && row.LastActivityTime != nil {
+			row.Inactive = now.Sub(*row.LastActivityTime) > time.Duration(opts.InactiveDays)*24*time.Hour
+		}
+		if opts.StaleInvitationAge > 0 && row.Pending && row.Since != nil {
+			row.StaleInvitation = now.Sub(*row.Since) > opts.StaleInvitationAge
+		}
+		review.Rows = append(review.Rows, row)
+	}
+
+	// The projects of the resolved teams, their memberships are listed from the teams below
+	teamProjects := make(map[[2]string]bool)
+	for _, t := range data.teams {
+		for _, tp := range t.projects {
+			teamProjects[[2]string{t.team.Id, tp.ProjectName}] = true
+		}
+	}
+
+	for _, p := range data.projects {
+		for _, u := range p.users {
+			switch {
+			case u.TeamId == "":
+				add(AccessReviewRow{UserEmail: u.Email, Project: p.name, Role: u.MemberType, Via: AccessViaDirect, Since: u.CreateTime})
+			case !teamProjects[[2]string{u.TeamId, p.name}]:
+				// The team was not resolved, e.g. it belongs to another account, report the membership as listed
+				add(AccessReviewRow{
+					UserEmail: u.Email,
+					Project:   p.name,
+					Role:      u.MemberType,
+					Via:       AccessViaAccountTeam,
+					ViaID:     u.TeamId,
+					ViaName:   u.TeamName,
+					Since:     u.CreateTime,
+				})
+			}
+		}
+
+		for _, i := range p.invitations {
+			add(AccessReviewRow{
+				UserEmail: i.UserEmail,
+				Project:   p.name,
+				Role:      i.MemberType,
+				Via:       AccessViaInvitation,
+				Pending:   true,
+				Since:     i.InviteTime,
+			})
+		}
+
+		for _, pg := range p.groups {
+			g, ok := groups[pg.OrganizationGroupID]
+			if !ok {
+				continue
+			}
+
+			for _, m := range g.members {
+				add(AccessReviewRow{
+					UserID:    m.UserID,
+					UserEmail: m.UserInfo.UserEmail,
+					Project:   p.name,
+					Role:      pg.Role,
+					Via:       AccessViaUserGroup,
+					ViaID:     g.group.UserGroupID,
+					ViaName:   g.group.UserGroupName,
+				})
+			}
+		}
+	}
+
+	for _, t := range data.teams {
+		for _, tp := range t.projects {
+			for _, m := range t.members {
+				add(AccessReviewRow{
+					UserID:    m.UserId,
+					UserEmail: m.UserEmail,
+					Project:   tp.ProjectName,
+					Role:      tp.TeamType,
+					Via:       AccessViaAccountTeam,
+					ViaID:     t.team.Id,
+					ViaName:   t.team.Name,
+					Since:     m.CreateTime,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(review.Rows, func(i, j int) bool {
+		a, b := review.Rows[i], review.Rows[j]
+		if a.Project != b.Project {
+			return a.Project < b.Project
+		}
+		if a.UserEmail != b.UserEmail {
+			return a.UserEmail < b.UserEmail
+		}
+		if a.Via != b.Via {
+			return a.Via < b.Via
+		}
+		return a.ViaName < b.ViaName
+	})
+
+	return review
+}
+
+// StaleInvitations returns the rows of pending invitations older than StaleInvitationAge.
+func (r *AccessReview) StaleInvitations() []AccessReviewRow {
+	var rows []AccessReviewRow
+	for _, row := range r.Rows {
+		if row.StaleInvitation {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// InactiveUsers returns the rows of users not active for InactiveDays.
+func (r *AccessReview) InactiveUsers() []AccessReviewRow {
+	var rows []AccessReviewRow
+	for _, row := range r.Rows {
+		if row.Inactive {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// WriteCSV writes the rows as CSV with a header row. Cells which a spreadsheet would evaluate
+// as a formula are prefixed with a single quote.
+func (r *AccessReview) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(accessReviewCSVHeader); err != nil {
+		return err
+	}
+
+	for _, row := range r.Rows {
+		err := cw.Write([]string{
+			csvCell(row.UserID),
+			csvCell(row.UserEmail),
+			csvCell(row.Project),
+			csvCell(row.Role),
+			csvCell(row.Via),
+			csvCell(row.ViaID),
+			csvCell(row.ViaName),
+			strconv.FormatBool(row.Pending),
+			csvTime(row.Since),
+			csvTime(row.LastActivityTime),
+			strconv.FormatBool(row.StaleInvitation),
+			strconv.FormatBool(row.Inactive),
+			strconv.FormatBool(row.ActivityUnknown),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// csvCell escapes a cell starting with a character a spreadsheet would evaluate as a formula.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
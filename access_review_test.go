@@ -0,0 +1,81 @@
+package aiven
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_buildAccessReview(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) *time.Time {
+		t := now.AddDate(0, 0, -d)
+		return &t
+	}
+
+	data := &accessReviewData{
+		users: []OrganizationMemberInfo{
+			{UserID: "u-1", LastActivityTime: daysAgo(120), UserInfo: OrganizationUserInfo{UserEmail: "alice@aiven.io"}},
+			{UserID: "u-2", LastActivityTime: daysAgo(10), UserInfo: OrganizationUserInfo{UserEmail: "bob@aiven.io"}},
+		},
+		teams: []accessReviewTeam{{
+			team:     AccountTeam{Id: "t-1", Name: "=HYPERLINK(\"http://example.com\")"},
+			members:  []AccountTeamMember{{UserId: "u-2", UserEmail: "bob@aiven.io"}},
+			projects: []AccountTeamProject{{ProjectName: "test-pr", TeamType: "operator"}},
+		}},
+		groups: []accessReviewGroup{{
+			group: OrganizationUserGroupResponse{UserGroupID: "ug-1", UserGroupName: "devs"},
+			members: []OrganizationUserGroupMember{
+				{UserID: "u-1", LastActivityTime: daysAgo(100), UserInfo: OrganizationUserGroupMemberUserInfo{UserEmail: "alice@aiven.io"}},
+			},
+		}},
+		projects: []accessReviewProject{{
+			name: "test-pr",
+			users: []*ProjectUser{
+				{Email: "alice@aiven.io", MemberType: "admin", CreateTime: daysAgo(300)},
+				{Email: "bob@aiven.io", MemberType: "operator", TeamId: "t-1", TeamName: "ops"},
+				{Email: "dave@aiven.io", MemberType: "developer", TeamId: "t-other", TeamName: "partners"},
+			},
+			invitations: []*ProjectInvitation{
+				{UserEmail: "carol@aiven.io", MemberType: "read_only", InviteTime: daysAgo(40)},
+			},
+			groups: []*ProjectUserGroup{
+				{OrganizationGroupID: "ug-1", Role: "developer"},
+				{OrganizationGroupID: "ug-unknown", Role: "admin"},
+			},
+		}},
+	}
+
+	review := buildAccessReview("org-1", data, AccessReviewOptions{StaleInvitationAge: 30 * 24 * time.Hour, InactiveDays: 90}, now)
+
+	want := []AccessReviewRow{
+		{UserID: "u-1", UserEmail: "alice@aiven.io", Project: "test-pr", Role: "admin", Via: AccessViaDirect, Since: daysAgo(300), LastActivityTime: daysAgo(100), Inactive: true},
+		{UserID: "u-1", UserEmail: "alice@aiven.io", Project: "test-pr", Role: "developer", Via: AccessViaUserGroup, ViaID: "ug-1", ViaName: "devs", LastActivityTime: daysAgo(100), Inactive: true},
+		{UserID: "u-2", UserEmail: "bob@aiven.io", Project: "test-pr", Role: "operator", Via: AccessViaAccountTeam, ViaID: "t-1", ViaName: `=HYPERLINK("http://example.com")`, LastActivityTime: daysAgo(10)},
+		{UserEmail: "carol@aiven.io", Project: "test-pr", Role: "read_only", Via: AccessViaInvitation, Pending: true, Since: daysAgo(40), StaleInvitation: true, ActivityUnknown: true},
+		{UserEmail: "dave@aiven.io", Project: "test-pr", Role: "developer", Via: AccessViaAccountTeam, ViaID: "t-other", ViaName: "partners", ActivityUnknown: true},
+	}
+	assert.Equal(t, want, review.Rows)
+	assert.Len(t, review.StaleInvitations(), 1)
+	assert.Len(t, review.InactiveUsers(), 2)
+
+	var buf bytes.Buffer
+	require.NoError(t, review.WriteCSV(&buf))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 6)
+	assert.Equal(t, accessReviewCSVHeader, records[0])
+	assert.Equal(t, []string{
+		"u-2", "bob@aiven.io", "test-pr", "operator", "account_team", "t-1", `'=HYPERLINK("http://example.com")`,
+		"false", "", "2024-05-22T00:00:00Z", "false", "false", "false",
+	}, records[3])
+	assert.Equal(t, []string{
+		"", "carol@aiven.io", "test-pr", "read_only", "invitation", "", "",
+		"true", "2024-04-22T00:00:00Z", "", "true", "false", "true",
+	}, records[4])
+}
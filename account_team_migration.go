@@ -0,0 +1,356 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// accountTeamTypeRoles maps account team project types to organization user group project roles.
+var accountTeamTypeRoles = map[string]string{
+	"admin":     "admin",
+	"developer": "developer",
+	"operator":  "operator",
+	"read_only": "read_only",
+}
+
+// accountTeamRoleRanks orders the user group project roles from the least to the most privileged.
+var accountTeamRoleRanks = map[string]int{
+	"read_only": 1,
+	"developer": 2,
+	"operator":  3,
+	"admin":     4,
+}
+
+type (
+	// AccountTeamMigrationOptions configures the migration of account teams to organization user groups.
+	AccountTeamMigrationOptions struct {
+		// DryRun only reports what would be migrated.
+		DryRun bool
+		// GroupName returns the user group name of a team, defaults to the team name.
+		// An existing user group with the same name is reused.
+		GroupName func(team AccountTeam) string
+	}
+
+	// AccountTeamMigration reports the migration of one account team.
+	AccountTeamMigration struct {
+		TeamID        string `json:"team_id"`
+		TeamName      string `json:"team_name"`
+		UserGroupID   string `json:"user_group_id,omitempty"`
+		UserGroupName string `json:"user_group_name"`
+		// GroupCreated is set when the user group did not exist before.
+		GroupCreated bool `json:"group_created"`
+		// AddedMembers lists the user IDs added to the user group.
+		AddedMembers []string `json:"added_members"`
+		// ProjectRoles maps the projects granted to the user group to their role. When teams share
+		// a user group, it is granted the highest of their roles on each project.
+		ProjectRoles map[string]string `json:"project_roles"`
+		// ChangedProjects lists the projects where the user group was granted its role or had another one.
+		ChangedProjects []string `json:"changed_projects"`
+	}
+
+	// AccountTeamMigrationIssue is something that could not be mapped to a user group.
+	AccountTeamMigrationIssue struct {
+		TeamID   string `json:"team_id"`
+		TeamName string `json:"team_name"`
+		Message  string `json:"message"`
+	}
+
+	// AccountTeamMigrationReport reports the migration of the account teams of an organization.
+	AccountTeamMigrationReport struct {
+		DryRun bool                        `json:"dry_run"`
+		Teams  []AccountTeamMigration      `json:"teams"`
+		Issues []AccountTeamMigrationIssue `json:"issues"`
+	}
+)
+
+// MigrateAccountTeams maps each account team of the organization to an organization user group,
+// copies its members and translates its project permissions to user group project roles.
+// Pending team invitations, members outside the organization, projects outside the organization
+// and unknown team types are reported as issues.
+func (c *Client) MigrateAccountTeams(ctx context.Context, organizationID string, opts AccountTeamMigrationOptions) (*AccountTeamMigrationReport, error) {
+	org, err := c.Organization.Get(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if org.AccountID == "" {
+		return nil, errors.New("organization has no account, there are no account teams to migrate")
+	}
+
+	teams, err := c.AccountTeams.List(ctx, org.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := c.OrganizationUser.List(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	orgUsers := make(map[string]bool, len(users.Users))
+	for _, u := range users.Users {
+		orgUsers[u.UserID] = true
+	}
+
+	projects, err := c.ProjectOrganization.OrganizationProjects(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	orgProjects := make(map[string]bool, len(projects))
+	for _, p := range projects {
+		orgProjects[p.Name] = true
+	}
+
+	groups, err := c.OrganizationUserGroups.List(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIDs := make(map[string]string, len(groups.UserGroups))
+	for _, g := range groups.UserGroups {
+		groupIDs[g.UserGroupName] = g.UserGroupID
+	}
+
+	m := &accountTeamMigrator{
+		client:         c,
+		opts:           opts,
+		organizationID: organizationID,
+		accountID:      org.AccountID,
+		orgUsers:       orgUsers,
+		orgProjects:    orgProjects,
+		groupIDs:       groupIDs,
+		groupMembers:   make(map[string]map[string]bool),
+		projectRoles:   make(map[string]map[string]string),
+		groupRoles:     make(map[string]map[string]string),
+		report:         &AccountTeamMigrationReport{DryRun: opts.DryRun},
+	}
+
+	// The projects of every team are merged first, so that a team sharing a user group
+	// with another one never overwrites a higher role of the other team
+	teamProjects := make([][]AccountTeamProject, len(teams.Teams))
+	for i, team := range teams.Teams {
+		projects, err := c.AccountTeamProjects.List(ctx, org.AccountID, team.Id)
+		if err != nil {
+			return m.report, fmt.Errorf("team %s: %w", team.Name, err)
+		}
+		teamProjects[i] = projects.Projects
+		m.mergeRoles(team, projects.Projects)
+	}
+
+	for i, team := range teams.Teams {
+		if err := m.migrate(ctx, team, teamProjects[i]); err != nil {
+			return m.report, fmt.Errorf("team %s: %w", team.Name, err)
+		}
+	}
+
+	return m.report, nil
+}
+
+// accountTeamMigrator holds the organization state shared by the team migrations.
+type accountTeamMigrator struct {
+	client         *Client
+	opts           AccountTeamMigrationOptions
+	organizationID string
+	accountID      string
+	orgUsers       map[string]bool
+	orgProjects    map[string]bool
+	// groupIDs maps user group names to their ID.
+	groupIDs map[string]string
+	// groupMembers maps user group names to their members, including the ones added, or planned
+	// in a dry run, by the teams migrated so far. Groups planned in a dry run have no ID.
+	groupMembers map[string]map[string]bool
+	// projectRoles maps projects to the roles of their user groups, keyed by accountTeamMigrator.groupKey.
+	projectRoles map[string]map[string]string
+	// groupRoles maps user group names to the highest role of their teams on each project.
+	groupRoles map[string]map[string]string
+	report     *AccountTeamMigrationReport
+}
+
+// groupName returns the user group name of the team.
+func (m *accountTeamMigrator) groupName(team AccountTeam) string {
+	if m.opts.GroupName != nil {
+		return m.opts.GroupName(team)
+	}
+
+	return team.Name
+}
+
+// mergeRoles keeps the highest role of the user group of the team on each of the team projects.
+func (m *accountTeamMigrator) mergeRoles(team AccountTeam, projects []AccountTeamProject) {
+	name := m.groupName(team)
+	if m.groupRoles[name] == nil {
+		m.groupRoles[name] = make(map[string]string)
+	}
+
+	for _, p := range projects {
+		role, ok := accountTeamTypeRoles[p.TeamType]
+		if !ok || !m.orgProjects[p.ProjectName] {
+			continue
+		}
+
+		if prev := m.groupRoles[name][p.ProjectName]; accountTeamRoleRanks[role] > accountTeamRoleRanks[prev] {
+			m.groupRoles[name][p.ProjectName] = role
+		}
+	}
+}
+
+// groupKey identifies a user group in projectRoles, by name for the groups planned in a dry run.
+func (m *accountTeamMigrator) groupKey(groupID, groupName string) string {
+	if groupID == "" {
+		return "planned:" + groupName
+	}
+
+	return groupID
+}
+
+// roles returns the roles of the user groups of the project, listed on first use.
+func (m *accountTeamMigrator) roles(ctx context.Context, project string) (map[string]string, error) {
+	if roles, ok := m.projectRoles[project]; ok {
+		return roles, nil
+	}
+
+	access, err := m.client.ProjectOrganization.List(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make(map[string]string, len(access))
+	for _, a := range access {
+		roles[a.OrganizationGroupID] = a.Role
+	}
+	m.projectRoles[project] = roles
+
+	return roles, nil
+}
+
+func (m *accountTeamMigrator) issue(team AccountTeam, format string, a ...interface{}) {
+	m.report.Issues = append(m.report.Issues, AccountTeamMigrationIssue{
+		TeamID:   team.Id,
+		TeamName: team.Name,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func (m *accountTeamMigrator) migrate(ctx context.Context, team AccountTeam, teamProjects []AccountTeamProject) error {
+	result := AccountTeamMigration{
+		TeamID:          team.Id,
+		TeamName:        team.Name,
+		UserGroupName:   m.groupName(team),
+		AddedMembers:    []string{},
+		ProjectRoles:    make(map[string]string),
+		ChangedProjects: []string{},
+	}
+
+	members, err := m.client.AccountTeamMembers.List(ctx, m.accountID, team.Id)
+	if err != nil {
+		return err
+	}
+
+	invites, err := m.client.AccountTeamInvites.List(ctx, m.accountID, team.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range invites.Invites {
+		m.issue(team, "pending invitation of %s cannot be migrated, invite the user to the organization", i.UserEmail)
+	}
+
+	for _, p := range teamProjects {
+		_, ok := accountTeamTypeRoles[p.TeamType]
+		switch {
+		case !ok:
+			m.issue(team, "project %s: unknown team type %q", p.ProjectName, p.TeamType)
+		case !m.orgProjects[p.ProjectName]:
+			m.issue(team, "project %s does not belong to the organization", p.ProjectName)
+		default:
+			result.ProjectRoles[p.ProjectName] = m.groupRoles[result.UserGroupName][p.ProjectName]
+		}
+	}
+
+	existing, known := m.groupMembers[result.UserGroupName]
+	groupID, found := m.groupIDs[result.UserGroupName]
+	switch {
+	case known:
+	case found:
+		groupMembers, err := m.client.OrganizationUserGroupMembers.List(ctx, m.organizationID, groupID)
+		if err != nil {
+			return err
+		}
+		existing = make(map[string]bool, len(groupMembers.Members))
+		for _, gm := range groupMembers.Members {
+			existing[gm.UserID] = true
+		}
+	case !m.opts.DryRun:
+		g, err := m.client.OrganizationUserGroups.Create(ctx, m.organizationID, OrganizationUserGroupRequest{
+			UserGroupName: result.UserGroupName,
+			Description:   fmt.Sprintf("Migrated from account team %s", team.Name),
+		})
+		if err != nil {
+			return err
+		}
+		groupID = g.UserGroupID
+		m.groupIDs[result.UserGroupName] = groupID
+		existing = make(map[string]bool)
+	default:
+		existing = make(map[string]bool)
+	}
+	m.groupMembers[result.UserGroupName] = existing
+	result.UserGroupID = groupID
+	result.GroupCreated = !found && !known
+
+	for _, tm := range members.Members {
+		switch {
+		case !m.orgUsers[tm.UserId]:
+			m.issue(team, "member %s is not an organization user", tm.UserEmail)
+		case !existing[tm.UserId]:
+			result.AddedMembers = append(result.AddedMembers, tm.UserId)
+		}
+	}
+
+	if len(result.AddedMembers) > 0 && !m.opts.DryRun {
+		err = m.client.OrganizationUserGroupMembers.Modify(ctx, m.organizationID, groupID, OrganizationUserGroupMemberRequest{
+			Operation: OrganizationGroupMemberAdd,
+			MemberIDs: result.AddedMembers,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, id := range result.AddedMembers {
+		existing[id] = true
+	}
+
+	projects := make([]string, 0, len(result.ProjectRoles))
+	for project := range result.ProjectRoles {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+
+	// Only the missing or different grants are written, so that a rerun changes nothing
+	key := m.groupKey(groupID, result.UserGroupName)
+	for _, project := range projects {
+		roles, err := m.roles(ctx, project)
+		if err != nil {
+			return err
+		}
+
+		role := result.ProjectRoles[project]
+		if roles[key] == role {
+			continue
+		}
+
+		if !m.opts.DryRun {
+			if err := m.client.ProjectOrganization.Add(ctx, project, groupID, role); err != nil {
+				return err
+			}
+		}
+		roles[key] = role
+		result.ChangedProjects = append(result.ChangedProjects, project)
+	}
+
+	m.report.Teams = append(m.report.Teams, result)
+	return nil
+}
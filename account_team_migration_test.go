@@ -0,0 +1,252 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAccountTeamMigrationTestCase(t *testing.T, opsTeamType string, access ...*ProjectUserGroup) (*Client, *[]string, func(t *testing.T)) {
+	t.Log("setup Account Team Migration test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+	)
+
+	var writes []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if r.Method != http.MethodGet && r.URL.Path != "/userauth" {
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+			b, _ := json.Marshal(body)
+			writes = append(writes, r.Method+" "+r.URL.Path+" "+string(b))
+		}
+
+		var rsp interface{}
+		switch r.URL.Path {
+		case "/userauth":
+			rsp = authResponse{Token: AccessToken, State: "active"}
+		case "/organization/org-1":
+			rsp = OrganizationInfo{ID: "org-1", AccountID: "a-1"}
+		case "/organization/org-1/user":
+			rsp = OrganizationUserList{Users: []OrganizationMemberInfo{{UserID: "u-1"}, {UserID: "u-2"}}}
+		case "/organization/org-1/projects":
+			rsp = ProjectListResponse{Projects: []*Project{{Name: "test-pr"}}}
+		case "/project/test-pr/access":
+			rsp = ProjectUserGroupListResponse{AccessList: access}
+		case "/organization/org-1/user-groups":
+			if r.Method == http.MethodPost {
+				rsp = OrganizationUserGroupResponse{UserGroupID: "ug-new", UserGroupName: "ops"}
+				break
+			}
+			rsp = OrganizationUserGroupListResponse{UserGroups: []OrganizationUserGroupResponse{
+				{UserGroupID: "ug-1", UserGroupName: "devs"},
+			}}
+		case "/organization/org-1/user-groups/ug-1/members":
+			rsp = OrganizationUserGroupMembersListResponse{Members: []OrganizationUserGroupMember{{UserID: "u-1"}}}
+		case "/account/a-1/teams":
+			rsp = AccountTeamsResponse{Teams: []AccountTeam{{Id: "t-1", Name: "devs"}, {Id: "t-2", Name: "ops"}}}
+		case "/account/a-1/team/t-1/members":
+			rsp = AccountTeamMembersResponse{Members: []AccountTeamMember{
+				{UserId: "u-1", UserEmail: "alice@aiven.io"},
+				{UserId: "u-2", UserEmail: "bob@aiven.io"},
+				{UserId: "u-3", UserEmail: "eve@example.com"},
+			}}
+		case "/account/a-1/team/t-1/projects":
+			rsp = AccountTeamProjectsResponse{Projects: []AccountTeamProject{
+				{ProjectName: "test-pr", TeamType: "developer"},
+				{ProjectName: "other-pr", TeamType: "admin"},
+			}}
+		case "/account/a-1/team/t-1/invites":
+			rsp = AccountTeamInvitesResponse{Invites: []AccountTeamInvite{{UserEmail: "carol@aiven.io"}}}
+		case "/account/a-1/team/t-2/members":
+			rsp = AccountTeamMembersResponse{Members: []AccountTeamMember{{UserId: "u-2"}}}
+		case "/account/a-1/team/t-2/projects":
+			rsp = AccountTeamProjectsResponse{Projects: []AccountTeamProject{{ProjectName: "test-pr", TeamType: opsTeamType}}}
+		case "/account/a-1/team/t-2/invites":
+			rsp = AccountTeamInvitesResponse{}
+		default:
+			if r.Method == http.MethodGet {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			rsp = struct{}{}
+		}
+
+		if err := json.NewEncoder(w).Encode(rsp); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	return c, &writes, func(t *testing.T) {
+		t.Log("teardown Account Team Migration test case")
+		ts.Close()
+	}
+}
+
+func TestClient_MigrateAccountTeams(t *testing.T) {
+	tests := []struct {
+		name       string
+		dryRun     bool
+		access     []*ProjectUserGroup
+		wantWrites []string
+		wantGrants []string
+	}{
+		{
+			name:       "dry run",
+			dryRun:     true,
+			wantGrants: []string{"test-pr"},
+		},
+		{
+			name: "migrate",
+			wantWrites: []string{
+				`PATCH /organization/org-1/user-groups/ug-1/members {"member_ids":["u-2"],"operation":"add_members"}`,
+				`PUT /project/test-pr/access/groups/ug-1 {"role":"developer"}`,
+				`POST /organization/org-1/user-groups {"description":"Migrated from account team ops","user_group_name":"ops"}`,
+				`PATCH /organization/org-1/user-groups/ug-new/members {"member_ids":["u-2"],"operation":"add_members"}`,
+			},
+			wantGrants: []string{"test-pr"},
+		},
+		{
+			name:   "existing grant",
+			access: []*ProjectUserGroup{{OrganizationGroupID: "ug-1", Role: "developer"}},
+			wantWrites: []string{
+				`PATCH /organization/org-1/user-groups/ug-1/members {"member_ids":["u-2"],"operation":"add_members"}`,
+				`POST /organization/org-1/user-groups {"description":"Migrated from account team ops","user_group_name":"ops"}`,
+				`PATCH /organization/org-1/user-groups/ug-new/members {"member_ids":["u-2"],"operation":"add_members"}`,
+			},
+			wantGrants: []string{},
+		},
+		{
+			name:   "different grant",
+			access: []*ProjectUserGroup{{OrganizationGroupID: "ug-1", Role: "read_only"}},
+			wantWrites: []string{
+				`PATCH /organization/org-1/user-groups/ug-1/members {"member_ids":["u-2"],"operation":"add_members"}`,
+				`PUT /project/test-pr/access/groups/ug-1 {"role":"developer"}`,
+				`POST /organization/org-1/user-groups {"description":"Migrated from account team ops","user_group_name":"ops"}`,
+				`PATCH /organization/org-1/user-groups/ug-new/members {"member_ids":["u-2"],"operation":"add_members"}`,
+			},
+			wantGrants: []string{"test-pr"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, writes, tearDown := setupAccountTeamMigrationTestCase(t, "superuser", tt.access...)
+			defer tearDown(t)
+
+			report, err := c.MigrateAccountTeams(context.Background(), "org-1", AccountTeamMigrationOptions{DryRun: tt.dryRun})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantWrites, *writes)
+
+			require.Len(t, report.Teams, 2)
+			assert.Equal(t, "ug-1", report.Teams[0].UserGroupID)
+			assert.False(t, report.Teams[0].GroupCreated)
+			assert.Equal(t, []string{"u-2"}, report.Teams[0].AddedMembers)
+			assert.Equal(t, map[string]string{"test-pr": "developer"}, report.Teams[0].ProjectRoles)
+			assert.Equal(t, tt.wantGrants, report.Teams[0].ChangedProjects)
+			assert.True(t, report.Teams[1].GroupCreated)
+			assert.Empty(t, report.Teams[1].ProjectRoles)
+
+			var messages []string
+			for _, i := range report.Issues {
+				messages = append(messages, i.Message)
+			}
+			assert.Equal(t, []string{
+				"pending invitation of carol@aiven.io cannot be migrated, invite the user to the organization",
+				"project other-pr does not belong to the organization",
+				"member eve@example.com is not an organization user",
+				`project test-pr: unknown team type "superuser"`,
+			}, messages)
+		})
+	}
+}
+
+func TestClient_MigrateAccountTeams_SharedGroup(t *testing.T) {
+	tests := []struct {
+		name        string
+		dryRun      bool
+		opsTeamType string
+		wantWrites  []string
+		wantChanged []string
+	}{
+		{
+			name:        "dry run",
+			dryRun:      true,
+			opsTeamType: "superuser",
+			wantChanged: []string{},
+		},
+		{
+			name:        "migrate",
+			opsTeamType: "superuser",
+			wantWrites: []string{
+				`POST /organization/org-1/user-groups {"description":"Migrated from account team devs","user_group_name":"platform"}`,
+				`PATCH /organization/org-1/user-groups/ug-new/members {"member_ids":["u-1","u-2"],"operation":"add_members"}`,
+				`PUT /project/test-pr/access/groups/ug-new {"role":"developer"}`,
+			},
+			wantChanged: []string{},
+		},
+		{
+			// The user group gets the highest role of the teams sharing it, whatever their order
+			name:        "lower role",
+			opsTeamType: "read_only",
+			wantWrites: []string{
+				`POST /organization/org-1/user-groups {"description":"Migrated from account team devs","user_group_name":"platform"}`,
+				`PATCH /organization/org-1/user-groups/ug-new/members {"member_ids":["u-1","u-2"],"operation":"add_members"}`,
+				`PUT /project/test-pr/access/groups/ug-new {"role":"developer"}`,
+			},
+			wantChanged: []string{},
+		},
+		{
+			name:        "higher role",
+			opsTeamType: "admin",
+			wantWrites: []string{
+				`POST /organization/org-1/user-groups {"description":"Migrated from account team devs","user_group_name":"platform"}`,
+				`PATCH /organization/org-1/user-groups/ug-new/members {"member_ids":["u-1","u-2"],"operation":"add_members"}`,
+				`PUT /project/test-pr/access/groups/ug-new {"role":"admin"}`,
+			},
+			wantChanged: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, writes, tearDown := setupAccountTeamMigrationTestCase(t, tt.opsTeamType)
+			defer tearDown(t)
+
+			// Both teams map to the same new user group, only the first one creates it
+			report, err := c.MigrateAccountTeams(context.Background(), "org-1", AccountTeamMigrationOptions{
+				DryRun: tt.dryRun,
+				GroupName: func(AccountTeam) string {
+					return "platform"
+				},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantWrites, *writes)
+
+			require.Len(t, report.Teams, 2)
+			assert.True(t, report.Teams[0].GroupCreated)
+			assert.Equal(t, []string{"u-1", "u-2"}, report.Teams[0].AddedMembers)
+			assert.Equal(t, []string{"test-pr"}, report.Teams[0].ChangedProjects)
+			assert.False(t, report.Teams[1].GroupCreated)
+			assert.Empty(t, report.Teams[1].AddedMembers)
+			assert.Equal(t, tt.wantChanged, report.Teams[1].ChangedProjects)
+		})
+	}
+}
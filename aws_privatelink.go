@@ -1,6 +1,9 @@
 package aiven
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 type (
 	// AWSPrivatelinkHandler is the client that interacts with the AWS Privatelink API on Aiven.
@@ -23,6 +26,20 @@ type (
 		State          string   `json:"state"`
 		Principals     []string `json:"principals"`
 	}
+
+	// AWSPrivatelinkConnectionsResponse is a response with a list of AWS Privatelink connections.
+	AWSPrivatelinkConnectionsResponse struct {
+		APIResponse
+		Connections []AWSPrivatelinkConnectionResponse `json:"connections"`
+	}
+
+	// AWSPrivatelinkConnectionResponse is a response with an AWS Privatelink connection details.
+	AWSPrivatelinkConnectionResponse struct {
+		DNSName                 string `json:"dns_name"`
+		PrivatelinkConnectionID string `json:"privatelink_connection_id"`
+		State                   string `json:"state"`
+		VPCEndpointID           string `json:"vpc_endpoint_id"`
+	}
 )
 
 // Create creates an AWS Privatelink
@@ -87,3 +104,87 @@ func (h *AWSPrivatelinkHandler) Delete(ctx context.Context, project, serviceName
 
 	return checkAPIResponse(rsp, nil)
 }
+
+// ConnectionsList lists AWS Privatelink connections.
+func (h *AWSPrivatelinkHandler) ConnectionsList(ctx context.Context, project, serviceName string) (*AWSPrivatelinkConnectionsResponse, error) {
+	path := buildPath("project", project, "service", serviceName, "privatelink", "aws", "connections")
+	bts, err := h.client.doGetRequest(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rsp AWSPrivatelinkConnectionsResponse
+	return &rsp, checkAPIResponse(bts, &rsp)
+}
+
+func newAWSPrivatelinkInfo(rsp *AWSPrivatelinkResponse) *PrivatelinkInfo {
+	return &PrivatelinkInfo{
+		Cloud:        PrivatelinkCloudAWS,
+		State:        newPrivatelinkState(rsp.State),
+		RawState:     rsp.State,
+		ServiceID:    rsp.AWSServiceID,
+		ServiceAlias: rsp.AWSServiceName,
+		AllowedIDs:   rsp.Principals,
+	}
+}
+
+// Cloud returns the cloud of the Privatelink.
+func (h *AWSPrivatelinkHandler) Cloud() string {
+	return PrivatelinkCloudAWS
+}
+
+// Enable creates the AWS Privatelink allowing the principals of the options.
+func (h *AWSPrivatelinkHandler) Enable(ctx context.Context, project, serviceName string, opts PrivatelinkOptions) (*PrivatelinkInfo, error) {
+	rsp, err := h.Create(ctx, project, serviceName, opts.AWSPrincipals)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAWSPrivatelinkInfo(rsp), nil
+}
+
+// Info retrieves the AWS Privatelink as a PrivatelinkInfo.
+func (h *AWSPrivatelinkHandler) Info(ctx context.Context, project, serviceName string) (*PrivatelinkInfo, error) {
+	rsp, err := h.Get(ctx, project, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAWSPrivatelinkInfo(rsp), nil
+}
+
+// Disable deletes the AWS Privatelink.
+func (h *AWSPrivatelinkHandler) Disable(ctx context.Context, project, serviceName string) error {
+	return h.Delete(ctx, project, serviceName)
+}
+
+// ListConnections lists the AWS Privatelink connections as PrivatelinkConnections.
+func (h *AWSPrivatelinkHandler) ListConnections(ctx context.Context, project, serviceName string) ([]PrivatelinkConnection, error) {
+	rsp, err := h.ConnectionsList(ctx, project, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	conns := make([]PrivatelinkConnection, 0, len(rsp.Connections))
+	for _, c := range rsp.Connections {
+		conns = append(conns, PrivatelinkConnection{
+			ID:         c.PrivatelinkConnectionID,
+			State:      newPrivatelinkConnectionState(c.State),
+			RawState:   c.State,
+			EndpointID: c.VPCEndpointID,
+			DNSName:    c.DNSName,
+		})
+	}
+
+	return conns, nil
+}
+
+// ApproveConnection is not supported, AWS connections are accepted through the allowed principals.
+func (h *AWSPrivatelinkHandler) ApproveConnection(context.Context, string, string, string, string) error {
+	return fmt.Errorf("%w: approve connection on %s", ErrPrivatelinkNotSupported, PrivatelinkCloudAWS)
+}
+
+// Refresh is not supported, AWS connections are refreshed by Aiven.
+func (h *AWSPrivatelinkHandler) Refresh(context.Context, string, string) error {
+	return fmt.Errorf("%w: refresh on %s", ErrPrivatelinkNotSupported, PrivatelinkCloudAWS)
+}
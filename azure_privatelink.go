@@ -185,3 +185,82 @@ func (h *AzurePrivatelinkHandler) ConnectionUpdate(ctx context.Context, project,
 
 	return checkAPIResponse(rsp, nil)
 }
+
+func newAzurePrivatelinkInfo(rsp *AzurePrivatelinkResponse) *PrivatelinkInfo {
+	return &PrivatelinkInfo{
+		Cloud:        PrivatelinkCloudAzure,
+		State:        newPrivatelinkState(rsp.State),
+		RawState:     rsp.State,
+		ServiceID:    rsp.AzureServiceID,
+		ServiceAlias: rsp.AzureServiceAlias,
+		AllowedIDs:   rsp.UserSubscriptionIDs,
+	}
+}
+
+// Cloud returns the cloud of the Privatelink.
+func (h *AzurePrivatelinkHandler) Cloud() string {
+	return PrivatelinkCloudAzure
+}
+
+// Enable creates the Azure Privatelink allowing the subscriptions of the options.
+func (h *AzurePrivatelinkHandler) Enable(ctx context.Context, project, serviceName string, opts PrivatelinkOptions) (*PrivatelinkInfo, error) {
+	rsp, err := h.Create(ctx, project, serviceName, AzurePrivatelinkRequest{UserSubscriptionIDs: opts.AzureSubscriptionIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	return newAzurePrivatelinkInfo(rsp), nil
+}
+
+// Info retrieves the Azure Privatelink as a PrivatelinkInfo.
+func (h *AzurePrivatelinkHandler) Info(ctx context.Context, project, serviceName string) (*PrivatelinkInfo, error) {
+	rsp, err := h.Get(ctx, project, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAzurePrivatelinkInfo(rsp), nil
+}
+
+// Disable deletes the Azure Privatelink.
+func (h *AzurePrivatelinkHandler) Disable(ctx context.Context, project, serviceName string) error {
+	return h.Delete(ctx, project, serviceName)
+}
+
+// ListConnections lists the Azure Privatelink connections as PrivatelinkConnections.
+func (h *AzurePrivatelinkHandler) ListConnections(ctx context.Context, project, serviceName string) ([]PrivatelinkConnection, error) {
+	rsp, err := h.ConnectionsList(ctx, project, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	conns := make([]PrivatelinkConnection, 0, len(rsp.Connections))
+	for _, c := range rsp.Connections {
+		conns = append(conns, PrivatelinkConnection{
+			ID:            c.PrivatelinkConnectionID,
+			State:         newPrivatelinkConnectionState(c.State),
+			RawState:      c.State,
+			EndpointID:    c.PrivateEndpointID,
+			UserIPAddress: c.UserIPAddress,
+		})
+	}
+
+	return conns, nil
+}
+
+// ApproveConnection sets the user IP address of the private endpoint on the connection and approves it.
+// Like on GCP, the user IP address is required.
+func (h *AzurePrivatelinkHandler) ApproveConnection(ctx context.Context, project, serviceName, connectionID, userIPAddress string) error {
+	if userIPAddress == "" {
+		return fmt.Errorf("%w: approve connection %s on %s", ErrPrivatelinkUserIPAddressRequired, connectionID, PrivatelinkCloudAzure)
+	}
+
+	err := h.ConnectionUpdate(ctx, project, serviceName, connectionID, AzurePrivatelinkConnectionUpdateRequest{
+		UserIPAddress: userIPAddress,
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.ConnectionApprove(ctx, project, serviceName, connectionID)
+}
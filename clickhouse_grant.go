@@ -0,0 +1,306 @@
+package aiven
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type (
+	// ClickhouseRoleHandler aiven go-client handler for Clickhouse Roles
+	ClickhouseRoleHandler struct {
+		client *Client
+	}
+
+	// ClickhouseGrantHandler aiven go-client handler for Clickhouse Grants
+	ClickhouseGrantHandler struct {
+		client *Client
+	}
+
+	// ClickhouseRole is a ClickHouse role
+	ClickhouseRole struct {
+		Name string `clickhouse:"name"`
+		ID   string `clickhouse:"id"`
+	}
+
+	// ClickhouseGrants is the set of privileges and roles granted to a user or a role
+	ClickhouseGrants struct {
+		Privileges []ClickhouseUserPrivilege
+		Roles      []ClickhouseUserRole
+	}
+)
+
+// Create creates a ClickHouse role
+func (h *ClickhouseRoleHandler) Create(ctx context.Context, project, service, name string) error {
+	return h.client.ClickHouseQuery.exec(ctx, project, service, "CREATE ROLE "+quoteClickhouseIdentifier(name))
+}
+
+// Delete deletes a ClickHouse role
+func (h *ClickhouseRoleHandler) Delete(ctx context.Context, project, service, name string) error {
+	return h.client.ClickHouseQuery.exec(ctx, project, service, "DROP ROLE "+quoteClickhouseIdentifier(name))
+}
+
+// List gets a list of ClickHouse roles for a service
+func (h *ClickhouseRoleHandler) List(ctx context.Context, project, service string) ([]ClickhouseRole, error) {
+	var roles []ClickhouseRole
+	err := h.client.ClickHouseQuery.QueryInto(ctx, project, service, "", "SELECT name, toString(id) AS id FROM system.roles ORDER BY name", nil, &roles)
+	return roles, err
+}
+
+// isClickhouseAccessType reports whether s is an access type keyword, e.g. SELECT or ALTER UPDATE.
+func isClickhouseAccessType(s string) bool {
+	for _, c := range s {
+		if c != ' ' && c != '_' && (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+			return false
+		}
+	}
+
+	return strings.TrimSpace(s) != ""
+}
+
+// clickhousePrivilegeTarget renders the privilege with its column and the database and table it applies to,
+// e.g. SELECT(`id`) ON `db`.*
+func clickhousePrivilegeTarget(p ClickhouseUserPrivilege) (string, error) {
+	if !isClickhouseAccessType(p.AccessType) {
+		return "", fmt.Errorf("invalid access type %q", p.AccessType)
+	}
+
+	s := strings.ToUpper(strings.TrimSpace(p.AccessType))
+	if p.Column != "" {
+		s += "(" + quoteClickhouseIdentifier(p.Column) + ")"
+	}
+
+	database, table := "*", "*"
+	if p.Database != "" {
+		database = quoteClickhouseIdentifier(p.Database)
+	}
+	if p.Table != "" {
+		table = quoteClickhouseIdentifier(p.Table)
+	}
+
+	return s + " ON " + database + "." + table, nil
+}
+
+// clickhousePrivilegeStatement renders the statement granting or revoking a privilege,
+// a partial revoke is granted with REVOKE and revoked with GRANT.
+func clickhousePrivilegeStatement(p ClickhouseUserPrivilege, grantee string, revoke bool) (string, error) {
+	target, err := clickhousePrivilegeTarget(p)
+	if err != nil {
+		return "", err
+	}
+
+	if revoke != p.IsPartialRevoke {
+		return "REVOKE " + target + " FROM " + quoteClickhouseIdentifier(grantee), nil
+	}
+
+	s := "GRANT " + target + " TO " + quoteClickhouseIdentifier(grantee)
+	if p.GrantOption {
+		s += " WITH GRANT OPTION"
+	}
+
+	return s, nil
+}
+
+// GrantPrivilege grants a privilege to a user or a role
+func (h *ClickhouseGrantHandler) GrantPrivilege(ctx context.Context, project, service, grantee string, p ClickhouseUserPrivilege) error {
+	s, err := clickhousePrivilegeStatement(p, grantee, false)
+	if err != nil {
+		return err
+	}
+
+	return h.client.ClickHouseQuery.exec(ctx, project, service, s)
+}
+
+// RevokePrivilege revokes a privilege from a user or a role
+func (h *ClickhouseGrantHandler) RevokePrivilege(ctx context.Context, project, service, grantee string, p ClickhouseUserPrivilege) error {
+	s, err := clickhousePrivilegeStatement(p, grantee, true)
+	if err != nil {
+		return err
+	}
+
+	return h.client.ClickHouseQuery.exec(ctx, project, service, s)
+}
+
+// GrantRole grants a role to a user or a role
+func (h *ClickhouseGrantHandler) GrantRole(ctx context.Context, project, service, grantee, role string, withAdminOption bool) error {
+	s := "GRANT " + quoteClickhouseIdentifier(role) + " TO " + quoteClickhouseIdentifier(grantee)
+	if withAdminOption {
+		s += " WITH ADMIN OPTION"
+	}
+
+	return h.client.ClickHouseQuery.exec(ctx, project, service, s)
+}
+
+// RevokeRole revokes a role from a user or a role
+func (h *ClickhouseGrantHandler) RevokeRole(ctx context.Context, project, service, grantee, role string) error {
+	return h.client.ClickHouseQuery.exec(ctx, project, service, "REVOKE "+quoteClickhouseIdentifier(role)+" FROM "+quoteClickhouseIdentifier(grantee))
+}
+
+// clickhousePrivilegeKey identifies a privilege regardless of its grant option
+func clickhousePrivilegeKey(p ClickhouseUserPrivilege) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%t", strings.ToUpper(strings.TrimSpace(p.AccessType)), p.Database, p.Table, p.Column, p.IsPartialRevoke)
+}
+
+// clickhouseGrantStatements diffs the current grants of a grantee against the desired ones and returns
+// the statements to apply, in this order:
+//   - the GRANT statements lifting partial revokes, before the privilege they are part of may be revoked,
+//     so that they do not grant the part on its own
+//   - the REVOKE statements of privileges, grant options, roles and admin options
+//   - the GRANT statements of privileges and roles
+//   - the REVOKE statements of partial revokes, once the privilege they are part of is granted
+//
+// Each group is sorted, so that the same diff always gives the same statements.
+func clickhouseGrantStatements(grantee string, current, desired ClickhouseGrants) ([]string, error) {
+	quoted := quoteClickhouseIdentifier(grantee)
+
+	currentPrivileges := make(map[string]ClickhouseUserPrivilege)
+	for _, p := range current.Privileges {
+		currentPrivileges[clickhousePrivilegeKey(p)] = p
+	}
+	desiredPrivileges := make(map[string]ClickhouseUserPrivilege)
+	for _, p := range desired.Privileges {
+		desiredPrivileges[clickhousePrivilegeKey(p)] = p
+	}
+	currentRoles := make(map[string]ClickhouseUserRole)
+	for _, r := range current.Roles {
+		currentRoles[r.Name] = r
+	}
+	desiredRoles := make(map[string]ClickhouseUserRole)
+	for _, r := range desired.Roles {
+		desiredRoles[r.Name] = r
+	}
+
+	var lifts, revokes, grants, partialRevokes []string
+	for k, p := range currentPrivileges {
+		d, ok := desiredPrivileges[k]
+		switch {
+		case !ok:
+			s, err := clickhousePrivilegeStatement(p, grantee, true)
+			if err != nil {
+				return nil, err
+			}
+			if p.IsPartialRevoke {
+				lifts = append(lifts, s)
+			} else {
+				revokes = append(revokes, s)
+			}
+		case p.GrantOption && !d.GrantOption && !p.IsPartialRevoke:
+			target, err := clickhousePrivilegeTarget(p)
+			if err != nil {
+				return nil, err
+			}
+			revokes = append(revokes, "REVOKE GRANT OPTION FOR "+target+" FROM "+quoted)
+		}
+	}
+
+	for k, p := range desiredPrivileges {
+		c, ok := currentPrivileges[k]
+		if ok && (c.GrantOption || !p.GrantOption || p.IsPartialRevoke) {
+			continue
+		}
+
+		s, err := clickhousePrivilegeStatement(p, grantee, false)
+		if err != nil {
+			return nil, err
+		}
+		if p.IsPartialRevoke {
+			partialRevokes = append(partialRevokes, s)
+		} else {
+			grants = append(grants, s)
+		}
+	}
+
+	for name, r := range currentRoles {
+		d, ok := desiredRoles[name]
+		switch {
+		case !ok:
+			revokes = append(revokes, "REVOKE "+quoteClickhouseIdentifier(name)+" FROM "+quoted)
+		case r.WithAdminOption && !d.WithAdminOption:
+			revokes = append(revokes, "REVOKE ADMIN OPTION FOR "+quoteClickhouseIdentifier(name)+" FROM "+quoted)
+		}
+	}
+
+	for name, r := range desiredRoles {
+		c, ok := currentRoles[name]
+		if ok && (c.WithAdminOption || !r.WithAdminOption) {
+			continue
+		}
+
+		s := "GRANT " + quoteClickhouseIdentifier(name) + " TO " + quoted
+		if r.WithAdminOption {
+			s += " WITH ADMIN OPTION"
+		}
+		grants = append(grants, s)
+	}
+
+	var statements []string
+	for _, group := range [][]string{lifts, revokes, grants, partialRevokes} {
+		sort.Strings(group)
+		statements = append(statements, group...)
+	}
+
+	return statements, nil
+}
+
+// syncGrants applies the statements making the current grants of a grantee match the desired ones.
+func (h *ClickhouseGrantHandler) syncGrants(ctx context.Context, project, service, grantee string, current, desired ClickhouseGrants) ([]string, error) {
+	statements, err := clickhouseGrantStatements(grantee, current, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, s := range statements {
+		if err := h.client.ClickHouseQuery.exec(ctx, project, service, s); err != nil {
+			return statements[:i], err
+		}
+	}
+
+	return statements, nil
+}
+
+// SyncGrants makes the privileges and roles of a user match the desired ones,
+// it returns the executed statements. The grants of a role are synced with SyncRoleGrants.
+func (h *ClickhouseGrantHandler) SyncGrants(ctx context.Context, project, service, userUUID string, desired ClickhouseGrants) ([]string, error) {
+	u, err := h.client.ClickhouseUser.Get(ctx, project, service, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.syncGrants(ctx, project, service, u.Name, ClickhouseGrants{Privileges: u.Privileges, Roles: u.Roles}, desired)
+}
+
+// RoleGrants gets the privileges and roles granted to a role
+func (h *ClickhouseGrantHandler) RoleGrants(ctx context.Context, project, service, role string) (*ClickhouseGrants, error) {
+	opts := &ClickhouseQueryOptions{Parameters: map[string]interface{}{"role": role}}
+
+	var grants ClickhouseGrants
+	err := h.client.ClickHouseQuery.QueryInto(ctx, project, service, "",
+		"SELECT toString(access_type) AS AccessType, database, table, column,"+
+			" grant_option AS GrantOption, is_partial_revoke AS IsPartialRevoke"+
+			" FROM system.grants WHERE role_name = {role:String}", opts, &grants.Privileges)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.client.ClickHouseQuery.QueryInto(ctx, project, service, "",
+		"SELECT granted_role_name AS Name, toString(granted_role_id) AS UUID, granted_role_is_default AS IsDefault,"+
+			" with_admin_option AS WithAdminOption"+
+			" FROM system.role_grants WHERE role_name = {role:String}", opts, &grants.Roles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grants, nil
+}
+
+// SyncRoleGrants makes the privileges and roles of a role match the desired ones,
+// it returns the executed statements.
+func (h *ClickhouseGrantHandler) SyncRoleGrants(ctx context.Context, project, service, role string, desired ClickhouseGrants) ([]string, error) {
+	current, err := h.RoleGrants(ctx, project, service, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.syncGrants(ctx, project, service, role, *current, desired)
+}
@@ -0,0 +1,162 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_clickhouseGrantStatements(t *testing.T) {
+	current := ClickhouseGrants{
+		Privileges: []ClickhouseUserPrivilege{
+			{AccessType: "SELECT", Database: "sales"},
+			{AccessType: "INSERT", Database: "sales", Table: "orders", GrantOption: true},
+			{AccessType: "ALTER UPDATE", Database: "tmp"},
+		},
+		Roles: []ClickhouseUserRole{{Name: "reader"}, {Name: "admin", WithAdminOption: true}},
+	}
+	desired := ClickhouseGrants{
+		Privileges: []ClickhouseUserPrivilege{
+			{AccessType: "select", Database: "sales"},
+			{AccessType: "INSERT", Database: "sales", Table: "orders"},
+			{AccessType: "SELECT", Database: "sales", Table: "cards", Column: "number", IsPartialRevoke: true},
+			{AccessType: "SHOW DATABASES", GrantOption: true},
+		},
+		Roles: []ClickhouseUserRole{{Name: "admin"}, {Name: "writer`x", WithAdminOption: true}},
+	}
+
+	got, err := clickhouseGrantStatements("ana", current, desired)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"REVOKE ADMIN OPTION FOR `admin` FROM `ana`",
+		"REVOKE ALTER UPDATE ON `tmp`.* FROM `ana`",
+		"REVOKE GRANT OPTION FOR INSERT ON `sales`.`orders` FROM `ana`",
+		"REVOKE `reader` FROM `ana`",
+		"GRANT SHOW DATABASES ON *.* TO `ana` WITH GRANT OPTION",
+		"GRANT `writer\\`x` TO `ana` WITH ADMIN OPTION",
+		"REVOKE SELECT(`number`) ON `sales`.`cards` FROM `ana`",
+	}, got)
+
+	got, err = clickhouseGrantStatements("ana", current, current)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	// A partial revoke is lifted before the privilege it is part of is revoked
+	got, err = clickhouseGrantStatements("ana", ClickhouseGrants{
+		Privileges: []ClickhouseUserPrivilege{
+			{AccessType: "SELECT", Database: "sales"},
+			{AccessType: "SELECT", Database: "sales", Table: "cards", IsPartialRevoke: true},
+		},
+		Roles: []ClickhouseUserRole{{Name: "auditor"}},
+	}, ClickhouseGrants{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"GRANT SELECT ON `sales`.`cards` TO `ana`",
+		"REVOKE SELECT ON `sales`.* FROM `ana`",
+		"REVOKE `auditor` FROM `ana`",
+	}, got)
+
+	_, err = clickhouseGrantStatements("ana", ClickhouseGrants{}, ClickhouseGrants{
+		Privileges: []ClickhouseUserPrivilege{{AccessType: "SELECT ON *.* TO x; --"}},
+	})
+	assert.Error(t, err)
+}
+
+func TestClickhouseGrantHandler_SyncGrants(t *testing.T) {
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+	)
+
+	var statements []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch r.URL.Path {
+		case "/userauth":
+			_ = json.NewEncoder(w).Encode(authResponse{Token: AccessToken, State: "active"})
+		case "/project/test-pr/service/test-sr/clickhouse/user":
+			_ = json.NewEncoder(w).Encode(ListClickhouseUserResponse{Users: []ClickhouseUser{{
+				Name:       "ana",
+				UUID:       "u-1",
+				Privileges: []ClickhouseUserPrivilege{{AccessType: "SELECT", Database: "sales"}},
+			}}})
+		case "/project/test-pr/service/test-sr/clickhouse/query":
+			var req ClickhouseQueryRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			switch {
+			case strings.Contains(req.Query, "FROM system.grants WHERE role_name = CAST('analyst' AS String)"):
+				_, _ = w.Write([]byte(`{
+					"meta": [
+						{"name": "AccessType", "type": "String"},
+						{"name": "database", "type": "Nullable(String)"},
+						{"name": "table", "type": "Nullable(String)"},
+						{"name": "column", "type": "Nullable(String)"},
+						{"name": "GrantOption", "type": "UInt8"},
+						{"name": "IsPartialRevoke", "type": "UInt8"}
+					],
+					"data": [["SELECT", "sales", null, null, 0, 0]]
+				}`))
+			case strings.Contains(req.Query, "FROM system.role_grants WHERE role_name = CAST('analyst' AS String)"):
+				_, _ = w.Write([]byte(`{
+					"meta": [
+						{"name": "Name", "type": "String"},
+						{"name": "UUID", "type": "String"},
+						{"name": "IsDefault", "type": "UInt8"},
+						{"name": "WithAdminOption", "type": "UInt8"}
+					],
+					"data": [["reader", "r-1", 1, 1]]
+				}`))
+			default:
+				statements = append(statements, req.Query)
+				_, _ = w.Write([]byte(`{"meta": [], "data": []}`))
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.ClickhouseRole.Create(ctx, "test-pr", "test-sr", "analyst"))
+
+	got, err := c.ClickhouseGrant.SyncGrants(ctx, "test-pr", "test-sr", "u-1", ClickhouseGrants{
+		Privileges: []ClickhouseUserPrivilege{{AccessType: "SELECT", Database: "sales", Table: "orders"}},
+		Roles:      []ClickhouseUserRole{{Name: "analyst"}},
+	})
+	require.NoError(t, err)
+
+	want := []string{
+		"REVOKE SELECT ON `sales`.* FROM `ana`",
+		"GRANT SELECT ON `sales`.`orders` TO `ana`",
+		"GRANT `analyst` TO `ana`",
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, append([]string{"CREATE ROLE `analyst`"}, want...), statements)
+
+	grants, err := c.ClickhouseGrant.RoleGrants(ctx, "test-pr", "test-sr", "analyst")
+	require.NoError(t, err)
+	assert.Equal(t, []ClickhouseUserPrivilege{{AccessType: "SELECT", Database: "sales"}}, grants.Privileges)
+	assert.Equal(t, []ClickhouseUserRole{{Name: "reader", UUID: "r-1", IsDefault: true, WithAdminOption: true}}, grants.Roles)
+
+	statements = nil
+	got, err = c.ClickhouseGrant.SyncRoleGrants(ctx, "test-pr", "test-sr", "analyst", ClickhouseGrants{
+		Privileges: []ClickhouseUserPrivilege{{AccessType: "SELECT", Database: "sales"}},
+		Roles:      []ClickhouseUserRole{{Name: "reader"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"REVOKE ADMIN OPTION FOR `reader` FROM `analyst`"}, got)
+	assert.Equal(t, got, statements)
+}
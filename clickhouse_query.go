@@ -30,7 +30,9 @@ type (
 		Database   string  `json:"database"`
 		Elapsed    float64 `json:"elapsed"`
 		Query      string  `json:"query"`
-		User       string  `json:"user"`
+		// QueryID is not part of the documented API response, it is empty when the API does not return it.
+		QueryID string `json:"query_id"`
+		User    string `json:"user"`
 	}
 
 	// ClickhouseCurrentQueriesResponse aiven go-client clickhouse current queries response
@@ -70,3 +72,9 @@ func (h *ClickhouseQueryHandler) Query(ctx context.Context, project, service, da
 
 	return &r, errR
 }
+
+// exec runs a statement which returns no rows
+func (h *ClickhouseQueryHandler) exec(ctx context.Context, project, service, statement string) error {
+	_, err := h.Query(ctx, project, service, "", statement)
+	return err
+}
@@ -0,0 +1,756 @@
+package aiven
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrClickhouseQueryTooManyRows is returned when a result has more rows than ClickhouseQueryOptions.MaxRows.
+var ErrClickhouseQueryTooManyRows = errors.New("clickhouse query returned too many rows")
+
+// clickhouseTimeLayouts are the layouts ClickHouse formats dates and times with.
+var clickhouseTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	time.RFC3339Nano,
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type (
+	// ClickhouseQueryOptions are the options of a ClickHouse query.
+	ClickhouseQueryOptions struct {
+		// Parameters are the values of the {name:Type} placeholders of the query, bound into the query text,
+		// see BindClickhouseQuery.
+		Parameters map[string]interface{}
+		// MaxRows limits the rows of the result, unlimited when zero. The query is run as a subquery limited
+		// to one row more, so it only applies to SELECT queries, and decoding fails with
+		// ErrClickhouseQueryTooManyRows once the result has more rows.
+		MaxRows int
+	}
+
+	// clickhouseRawQueryResponse keeps the rows of a query response undecoded.
+	clickhouseRawQueryResponse struct {
+		APIResponse
+		Meta []ClickhouseQueryColumnMeta `json:"meta"`
+		Data json.RawMessage             `json:"data"`
+	}
+
+	// ClickhouseRows iterates the rows of a ClickHouse query result. The response is read whole,
+	// each row is decoded when Next moves to it.
+	ClickhouseRows struct {
+		meta    []ClickhouseQueryColumnMeta
+		dec     *json.Decoder
+		maxRows int
+		count   int
+		current []json.RawMessage
+		err     error
+	}
+)
+
+// Rows runs a query and returns an iterator over its rows.
+func (h *ClickhouseQueryHandler) Rows(ctx context.Context, project, service, database, query string, opts *ClickhouseQueryOptions) (*ClickhouseRows, error) {
+	if opts == nil {
+		opts = &ClickhouseQueryOptions{}
+	}
+
+	query, err := BindClickhouseQuery(query, opts.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	// The extra row tells a result which was cut from one which has exactly MaxRows rows,
+	// the query is on its own lines so that a trailing comment does not hide the parenthesis
+	if opts.MaxRows > 0 {
+		query = fmt.Sprintf("SELECT * FROM (\n%s\n) LIMIT %d", strings.TrimRight(query, "; \t\r\n"), opts.MaxRows+1)
+	}
+
+	path := buildPath("project", project, "service", service, "clickhouse", "query")
+	bts, err := h.client.doPostRequest(ctx, path, ClickhouseQueryRequest{
+		Database: database,
+		Query:    query,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var r clickhouseRawQueryResponse
+	if err := checkAPIResponse(bts, &r); err != nil {
+		return nil, err
+	}
+
+	data := r.Data
+	if len(data) == 0 || string(data) == "null" {
+		data = []byte("[]")
+	}
+
+	rows := &ClickhouseRows{meta: r.Meta, maxRows: opts.MaxRows, dec: json.NewDecoder(bytes.NewReader(data))}
+	if t, err := rows.dec.Token(); err != nil || t != json.Delim('[') {
+		return nil, fmt.Errorf("clickhouse query data is not a list: %s", data)
+	}
+
+	return rows, nil
+}
+
+// QueryInto runs a query and decodes its rows into dest, a pointer to a slice of structs or of struct pointers.
+// Columns are matched to fields by their `clickhouse` tag or, without one, case-insensitively by field name.
+func (h *ClickhouseQueryHandler) QueryInto(ctx context.Context, project, service, database, query string, opts *ClickhouseQueryOptions, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dest must be a pointer to a slice, got %T", dest)
+	}
+
+	elemType := v.Elem().Type().Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Ptr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("dest must be a pointer to a slice of structs, got %T", dest)
+	}
+
+	rows, err := h.Rows(ctx, project, service, database, query, opts)
+	if err != nil {
+		return err
+	}
+
+	result := reflect.MakeSlice(v.Elem().Type(), 0, 0)
+	for rows.Next() {
+		item := reflect.New(structType)
+		if err := rows.ScanStruct(item.Interface()); err != nil {
+			return err
+		}
+		if elemType.Kind() != reflect.Ptr {
+			item = item.Elem()
+		}
+		result = reflect.Append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	v.Elem().Set(result)
+	return nil
+}
+
+// Columns returns the columns of the result.
+func (r *ClickhouseRows) Columns() []ClickhouseQueryColumnMeta {
+	return r.meta
+}
+
+// Next advances to the next row, returns false when there are no more rows or decoding failed, see Err.
+func (r *ClickhouseRows) Next() bool {
+	if r.err != nil || !r.dec.More() {
+		return false
+	}
+
+	if r.maxRows > 0 && r.count >= r.maxRows {
+		r.err = fmt.Errorf("%w: more than %d", ErrClickhouseQueryTooManyRows, r.maxRows)
+		return false
+	}
+
+	var raw json.RawMessage
+	if err := r.dec.Decode(&raw); err != nil {
+		r.err = err
+		return false
+	}
+
+	values, err := r.rowValues(raw)
+	if err != nil {
+		r.err = fmt.Errorf("row %d: %w", r.count, err)
+		return false
+	}
+
+	r.current = values
+	r.count++
+	return true
+}
+
+// rowValues splits a row, either a list of values in the column order or an object keyed by column name.
+func (r *ClickhouseRows) rowValues(raw json.RawMessage) ([]json.RawMessage, error) {
+	var values []json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		var byName map[string]json.RawMessage
+		if errM := json.Unmarshal(raw, &byName); errM != nil {
+			return nil, fmt.Errorf("data is neither a list nor an object: %w", err)
+		}
+		values = make([]json.RawMessage, len(r.meta))
+		for i, c := range r.meta {
+			values[i] = byName[c.Name]
+		}
+	}
+
+	if len(values) != len(r.meta) {
+		return nil, fmt.Errorf("%d values for %d columns", len(values), len(r.meta))
+	}
+
+	return values, nil
+}
+
+// Err returns the error which stopped the iteration.
+func (r *ClickhouseRows) Err() error {
+	return r.err
+}
+
+// Scan decodes the current row into dest, a pointer per column.
+func (r *ClickhouseRows) Scan(dest ...interface{}) error {
+	if len(dest) != len(r.meta) {
+		return fmt.Errorf("%d destinations for %d columns", len(dest), len(r.meta))
+	}
+
+	for i, d := range dest {
+		v := reflect.ValueOf(d)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return fmt.Errorf("column %s: destination must be a pointer, got %T", r.meta[i].Name, d)
+		}
+		if err := assignClickhouseValue(v.Elem(), r.meta[i].Type, r.current[i]); err != nil {
+			return fmt.Errorf("column %s: %w", r.meta[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ScanStruct decodes the current row into the fields of dest, a pointer to a struct.
+// Columns without a matching field are skipped.
+func (r *ClickhouseRows) ScanStruct(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dest must be a pointer to a struct, got %T", dest)
+	}
+
+	fields := clickhouseStructFields(v.Elem().Type(), nil)
+	for i, c := range r.meta {
+		index, ok := fields[strings.ToLower(c.Name)]
+		if !ok {
+			continue
+		}
+		if err := assignClickhouseValue(v.Elem().FieldByIndex(index), c.Type, r.current[i]); err != nil {
+			return fmt.Errorf("column %s: %w", c.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Values decodes the current row into the Go values of the column types, see DecodeClickhouseValue.
+func (r *ClickhouseRows) Values() ([]interface{}, error) {
+	values := make([]interface{}, len(r.meta))
+	for i, c := range r.meta {
+		v, err := DecodeClickhouseValue(c.Type, r.current[i])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", c.Name, err)
+		}
+		values[i] = v
+	}
+
+	return values, nil
+}
+
+// clickhouseStructFields maps lower-cased column names to the field indexes of a struct,
+// fields of embedded structs are promoted.
+func clickhouseStructFields(t reflect.Type, prefix []int) map[string][]int {
+	fields := make(map[string][]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("clickhouse")
+		if tag == "-" {
+			continue
+		}
+
+		index := append(append([]int(nil), prefix...), i)
+		if f.Anonymous && !hasTag && f.Type.Kind() == reflect.Struct {
+			for name, idx := range clickhouseStructFields(f.Type, index) {
+				if _, ok := fields[name]; !ok {
+					fields[name] = idx
+				}
+			}
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		if tag != "" {
+			name = tag
+		}
+		fields[strings.ToLower(name)] = index
+	}
+
+	return fields
+}
+
+// parseClickhouseType splits a ClickHouse type into its name and top level arguments,
+// e.g. Map(String, Array(UInt8)) into Map and [String Array(UInt8)].
+func parseClickhouseType(t string) (string, []string) {
+	t = strings.TrimSpace(t)
+	i := strings.IndexByte(t, '(')
+	if i < 0 || !strings.HasSuffix(t, ")") {
+		return t, nil
+	}
+
+	var args []string
+	inner := t[i+1 : len(t)-1]
+	depth, start, quoted := 0, 0, false
+	for j := 0; j < len(inner); j++ {
+		switch c := inner[j]; {
+		case c == '\\' && quoted:
+			j++
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(inner[start:j]))
+			start = j + 1
+		}
+	}
+
+	return strings.TrimSpace(t[:i]), append(args, strings.TrimSpace(inner[start:]))
+}
+
+// clickhouseText returns the text of a scalar JSON value, ClickHouse quotes 64-bit integers.
+func clickhouseText(raw json.RawMessage) (string, error) {
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+
+	return string(raw), nil
+}
+
+// parseClickhouseTime parses a Date, Date32, DateTime or DateTime64 value in the time zone of the type, UTC by default.
+func parseClickhouseTime(name string, args []string, s string) (time.Time, error) {
+	tz := ""
+	switch {
+	case name == "DateTime" && len(args) > 0:
+		tz = args[0]
+	case name == "DateTime64" && len(args) > 1:
+		tz = args[1]
+	}
+
+	loc := time.UTC
+	if tz = strings.Trim(tz, "' "); tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return time.Time{}, err
+		}
+		loc = l
+	}
+
+	for _, layout := range clickhouseTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot parse %s value %q", name, s)
+}
+
+// assignClickhouseValue decodes a JSON value of the ClickHouse type into v.
+func assignClickhouseValue(v reflect.Value, chType string, raw json.RawMessage) error {
+	name, args := parseClickhouseType(chType)
+	if name == "LowCardinality" && len(args) == 1 {
+		return assignClickhouseValue(v, args[0], raw)
+	}
+
+	null := len(raw) == 0 || string(raw) == "null"
+	switch {
+	case v.Kind() == reflect.Ptr:
+		if null {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assignClickhouseValue(v.Elem(), chType, raw)
+	case name == "Nullable" && len(args) == 1:
+		if null {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		return assignClickhouseValue(v, args[0], raw)
+	case v.Kind() == reflect.Interface:
+		x, err := DecodeClickhouseValue(chType, raw)
+		if err != nil {
+			return err
+		}
+		if x == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		xv := reflect.ValueOf(x)
+		if !xv.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("cannot decode %s into %s", chType, v.Type())
+		}
+		v.Set(xv)
+		return nil
+	case null:
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	case name == "Array" && len(args) == 1 && v.Kind() == reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assignClickhouseValue(s.Index(i), args[0], item); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		v.Set(s)
+		return nil
+	case name == "Map" && len(args) == 2 && v.Kind() == reflect.Map:
+		var items map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(items))
+		for k, item := range items {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := assignClickhouseText(key, args[0], k); err != nil {
+				return fmt.Errorf("key %q: %w", k, err)
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := assignClickhouseValue(value, args[1], item); err != nil {
+				return fmt.Errorf("key %q: %w", k, err)
+			}
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+		return nil
+	case raw[0] == '[' || raw[0] == '{':
+		if v.Kind() == reflect.String {
+			v.SetString(string(raw))
+			return nil
+		}
+		return json.Unmarshal(raw, v.Addr().Interface())
+	}
+
+	s, err := clickhouseText(raw)
+	if err != nil {
+		return err
+	}
+
+	return assignClickhouseText(v, chType, s)
+}
+
+// assignClickhouseText decodes the text of a scalar value of the ClickHouse type into v.
+func assignClickhouseText(v reflect.Value, chType string, s string) error {
+	name, args := parseClickhouseType(chType)
+	for (name == "Nullable" || name == "LowCardinality") && len(args) == 1 {
+		name, args = parseClickhouseType(args[0])
+	}
+
+	if v.Type() == timeType {
+		t, err := parseClickhouseTime(name, args, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("cannot decode %s into %s", chType, v.Type())
+		}
+		v.SetBytes([]byte(s))
+	default:
+		return fmt.Errorf("cannot decode %s into %s", chType, v.Type())
+	}
+
+	return nil
+}
+
+// DecodeClickhouseValue converts a JSON value to the Go value of the ClickHouse type:
+// Int8 to Int64 to int64, UInt8 to UInt64 to uint64, floats to float64, Bool to bool,
+// Decimal and the 128 and 256-bit integers to their exact string, Date and DateTime types to time.Time,
+// Array to []interface{}, Map to map[string]interface{} and String, UUID, Enum and IP types to string.
+// Other types, e.g. Tuple or JSON, are decoded generically.
+func DecodeClickhouseValue(chType string, raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	name, args := parseClickhouseType(chType)
+	var target interface{}
+	switch {
+	case (name == "Nullable" || name == "LowCardinality") && len(args) == 1:
+		return DecodeClickhouseValue(args[0], raw)
+	case name == "Int8", name == "Int16", name == "Int32", name == "Int64":
+		target = new(int64)
+	case name == "UInt8", name == "UInt16", name == "UInt32", name == "UInt64":
+		target = new(uint64)
+	case name == "Float32", name == "Float64":
+		target = new(float64)
+	case name == "Bool":
+		target = new(bool)
+	case name == "Date", name == "Date32", name == "DateTime", name == "DateTime64":
+		target = new(time.Time)
+	case name == "Array":
+		target = new([]interface{})
+	case name == "Map":
+		target = new(map[string]interface{})
+	case strings.HasPrefix(name, "Decimal"), strings.HasPrefix(name, "Enum"),
+		name == "Int128", name == "Int256", name == "UInt128", name == "UInt256",
+		name == "String", name == "FixedString", name == "UUID", name == "IPv4", name == "IPv6":
+		target = new(string)
+	default:
+		var v interface{}
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.UseNumber()
+		err := d.Decode(&v)
+		return v, err
+	}
+
+	v := reflect.ValueOf(target).Elem()
+	if err := assignClickhouseValue(v, chType, raw); err != nil {
+		return nil, err
+	}
+
+	return v.Interface(), nil
+}
+
+// BindClickhouseQuery replaces the {name:Type} placeholders of a query, which are ClickHouse's query parameter
+// syntax, with the values of params as literals cast to the placeholder type, e.g. {id:UInt64} with 1 becomes
+// CAST(1 AS UInt64), and an Identifier with a quoted identifier. The query API only takes the query text,
+// strings are quoted and escaped so that a value never changes the structure of the query. Times are rendered
+// in UTC. Placeholders in string literals, quoted identifiers and comments are left as they are.
+func BindClickhouseQuery(query string, params map[string]interface{}) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(query); {
+		c := query[i]
+		j := i + 1
+		literal := ""
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			for j < len(query) {
+				if query[j] == '\\' {
+					j += 2
+					continue
+				}
+				j++
+				if query[j-1] == c {
+					if j < len(query) && query[j] == c {
+						j++
+						continue
+					}
+					break
+				}
+			}
+		case strings.HasPrefix(query[i:], "--"):
+			if j = strings.IndexByte(query[i:], '\n'); j < 0 {
+				j = len(query)
+			} else {
+				j += i
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			if j = strings.Index(query[i+2:], "*/"); j < 0 {
+				j = len(query)
+			} else {
+				j += i + 4
+			}
+		case c == '{':
+			end := strings.IndexByte(query[i:], '}')
+			if end < 0 {
+				break
+			}
+			name, typ, ok := strings.Cut(query[i+1:i+end], ":")
+			name, typ = strings.TrimSpace(name), strings.TrimSpace(typ)
+			if !ok || !isClickhouseIdentifier(name) || typ == "" {
+				break
+			}
+
+			v, ok := params[name]
+			if !ok {
+				return "", fmt.Errorf("clickhouse query parameter %s is not set", name)
+			}
+
+			var err error
+			if literal, err = formatClickhouseParameter(v, typ); err != nil {
+				return "", fmt.Errorf("clickhouse query parameter %s: %w", name, err)
+			}
+			j = i + end + 1
+		}
+
+		if j > len(query) {
+			j = len(query)
+		}
+		if literal != "" {
+			b.WriteString(literal)
+		} else {
+			b.WriteString(query[i:j])
+		}
+		i = j
+	}
+
+	return b.String(), nil
+}
+
+// isClickhouseIdentifier reports whether s is a bare identifier.
+func isClickhouseIdentifier(s string) bool {
+	for i, c := range s {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// formatClickhouseParameter renders a parameter value as a literal of the type.
+func formatClickhouseParameter(v interface{}, typ string) (string, error) {
+	if typ == "Identifier" {
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("identifier must be a string, got %T", v)
+		}
+		return quoteClickhouseIdentifier(s), nil
+	}
+
+	literal, err := formatClickhouseLiteral(v)
+	if err != nil {
+		return "", err
+	}
+
+	return "CAST(" + literal + " AS " + typ + ")", nil
+}
+
+// quoteClickhouseString quotes s as a string literal.
+func quoteClickhouseString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// quoteClickhouseIdentifier quotes s as an identifier.
+func quoteClickhouseIdentifier(s string) string {
+	return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(s) + "`"
+}
+
+// formatClickhouseLiteral renders a Go value as a ClickHouse literal, slices as arrays and maps with the map function.
+func formatClickhouseLiteral(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "NULL", nil
+	case []byte:
+		return quoteClickhouseString(string(x)), nil
+	case time.Time:
+		return quoteClickhouseString(x.UTC().Format("2006-01-02 15:04:05.999999999")), nil
+	case json.Number:
+		if _, err := strconv.ParseFloat(x.String(), 64); err != nil {
+			return "", err
+		}
+		return x.String(), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return quoteClickhouseString(rv.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		switch {
+		case math.IsNaN(f):
+			return "nan", nil
+		case math.IsInf(f, 1):
+			return "inf", nil
+		case math.IsInf(f, -1):
+			return "-inf", nil
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return formatClickhouseLiteral(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		items := make([]string, rv.Len())
+		for i := range items {
+			t, err := formatClickhouseLiteral(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			items[i] = t
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case reflect.Map:
+		pairs := make([][2]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			tk, err := formatClickhouseLiteral(k.Interface())
+			if err != nil {
+				return "", err
+			}
+			tv, err := formatClickhouseLiteral(rv.MapIndex(k).Interface())
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, [2]string{tk, tv})
+		}
+		sort.Slice(pairs, func(i, j int) bool {
+			return pairs[i][0] < pairs[j][0]
+		})
+
+		items := make([]string, 0, 2*len(pairs))
+		for _, p := range pairs {
+			items = append(items, p[0], p[1])
+		}
+		return "map(" + strings.Join(items, ", ") + ")", nil
+	}
+
+	return "", fmt.Errorf("unsupported parameter type %T", v)
+}
@@ -0,0 +1,172 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindClickhouseQuery(t *testing.T) {
+	got, err := BindClickhouseQuery(
+		"SELECT * FROM {table:Identifier} WHERE name = {name:String} AND id IN {ids:Array(UInt64)}"+
+			" AND note != '{note:String}' -- {note:String}\n AND ts > {since:DateTime64(3)} AND tags = {tags:Map(String, Int32)}"+
+			" AND labels = {labels:Array(Nullable(String))} AND deleted = {deleted:Nullable(Bool)}",
+		map[string]interface{}{
+			"table":   "events`; DROP TABLE x",
+			"name":    "o'neil\\\tx",
+			"ids":     []uint64{1, 2},
+			"since":   time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC),
+			"tags":    map[string]int32{"b": 2, "a": 1},
+			"labels":  []*string{ToStringPointer("it's"), nil},
+			"deleted": nil,
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `events\\`; DROP TABLE x` WHERE name = CAST('o\\'neil\\\\\tx' AS String)"+
+		" AND id IN CAST([1, 2] AS Array(UInt64))"+
+		" AND note != '{note:String}' -- {note:String}\n AND ts > CAST('2024-01-02 03:04:05.006' AS DateTime64(3))"+
+		" AND tags = CAST(map('a', 1, 'b', 2) AS Map(String, Int32))"+
+		" AND labels = CAST(['it\\'s', NULL] AS Array(Nullable(String))) AND deleted = CAST(NULL AS Nullable(Bool))", got)
+
+	_, err = BindClickhouseQuery("SELECT {missing:String}", nil)
+	assert.Error(t, err)
+
+	got, err = BindClickhouseQuery("SELECT {'a': 1}, {x}, 'it''s {x:String}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT {'a': 1}, {x}, 'it''s {x:String}", got)
+}
+
+func TestDecodeClickhouseValue(t *testing.T) {
+	tests := []struct {
+		chType string
+		raw    string
+		want   interface{}
+	}{
+		{"UInt64", `"18446744073709551615"`, uint64(18446744073709551615)},
+		{"Int32", `-5`, int64(-5)},
+		{"Nullable(Float64)", `null`, nil},
+		{"Decimal(18, 4)", `10.1000`, "10.1000"},
+		{"LowCardinality(String)", `"a"`, "a"},
+		{"DateTime64(3, 'Europe/Helsinki')", `"2024-01-02 03:04:05.678"`, time.Date(2024, 1, 2, 1, 4, 5, 678000000, time.UTC)},
+		{"Array(Nullable(Int64))", `["1", null]`, []interface{}{int64(1), nil}},
+		{"Map(String, Array(UInt8))", `{"a": [1, 2]}`, map[string]interface{}{"a": []interface{}{uint64(1), uint64(2)}}},
+		{"UUID", `"61f2cb58-a9d9-4a8b-8d4b-6b2d1d0b7d1e"`, "61f2cb58-a9d9-4a8b-8d4b-6b2d1d0b7d1e"},
+		{"Tuple(Int8, String)", `[1, "a"]`, []interface{}{json.Number("1"), "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chType, func(t *testing.T) {
+			got, err := DecodeClickhouseValue(tt.chType, json.RawMessage(tt.raw))
+			require.NoError(t, err)
+			if want, ok := tt.want.(time.Time); ok {
+				assert.True(t, want.Equal(got.(time.Time)), "got %v", got)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClickhouseQueryHandler_QueryInto(t *testing.T) {
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+	)
+
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch r.URL.Path {
+		case "/userauth":
+			_ = json.NewEncoder(w).Encode(authResponse{Token: AccessToken, State: "active"})
+		case "/project/test-pr/service/test-sr/clickhouse/query":
+			var req ClickhouseQueryRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			query = req.Query
+			_, _ = w.Write([]byte(`{
+				"data": [
+					["1", "alice", "12.50", "2024-01-02 03:04:05.000", ["a", "b"], {"k": "1"}, null],
+					["2", "bob", "0.10", "2024-01-03 00:00:00.000", [], {}, "x"]
+				],
+				"meta": [
+					{"name": "id", "type": "UInt64"},
+					{"name": "name", "type": "String"},
+					{"name": "amount", "type": "Decimal(10, 2)"},
+					{"name": "created", "type": "DateTime64(3)"},
+					{"name": "tags", "type": "Array(LowCardinality(String))"},
+					{"name": "counts", "type": "Map(String, UInt32)"},
+					{"name": "note", "type": "Nullable(String)"}
+				]
+			}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	require.NoError(t, err)
+
+	type account struct {
+		ID      uint64 `clickhouse:"id"`
+		Name    string
+		Amount  string
+		Created time.Time
+		Tags    []string
+		Counts  map[string]uint32
+		Note    *string
+	}
+
+	ctx := context.Background()
+	opts := &ClickhouseQueryOptions{Parameters: map[string]interface{}{"min": 1}}
+
+	var accounts []account
+	require.NoError(t, c.ClickHouseQuery.QueryInto(ctx, "test-pr", "test-sr", "db", "SELECT * FROM accounts WHERE id >= {min:UInt64}", opts, &accounts))
+	assert.Equal(t, "SELECT * FROM accounts WHERE id >= CAST(1 AS UInt64)", query)
+	require.Len(t, accounts, 2)
+	assert.Equal(t, account{
+		ID:      1,
+		Name:    "alice",
+		Amount:  "12.50",
+		Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Tags:    []string{"a", "b"},
+		Counts:  map[string]uint32{"k": 1},
+	}, accounts[0])
+	assert.Equal(t, "x", *accounts[1].Note)
+	assert.Equal(t, []string{}, accounts[1].Tags)
+
+	var pointers []*account
+	err = c.ClickHouseQuery.QueryInto(ctx, "test-pr", "test-sr", "db", "SELECT 1 -- one;\n", &ClickhouseQueryOptions{MaxRows: 1}, &pointers)
+	assert.ErrorIs(t, err, ErrClickhouseQueryTooManyRows)
+	assert.Equal(t, "SELECT * FROM (\nSELECT 1 -- one\n) LIMIT 2", query)
+
+	rows, err := c.ClickHouseQuery.Rows(ctx, "test-pr", "test-sr", "db", "SELECT 1", nil)
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	var (
+		id     uint64
+		name   string
+		amount float64
+		rest   [4]interface{}
+	)
+	require.NoError(t, rows.Scan(&id, &name, &amount, &rest[0], &rest[1], &rest[2], &rest[3]))
+	assert.Equal(t, uint64(1), id)
+	assert.Equal(t, 12.5, amount)
+	assert.Nil(t, rest[3])
+
+	values, err := rows.Values()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, values[4])
+	assert.True(t, rows.Next())
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.Err())
+}
@@ -0,0 +1,227 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrClickhouseQueryIDUnknown is returned when killing a query the current queries report neither an ID
+// nor a user and a text for.
+var ErrClickhouseQueryIDUnknown = errors.New("clickhouse query has no query ID")
+
+const (
+	// ClickhouseQueryActionReported is a long-running query which is only reported.
+	ClickhouseQueryActionReported = "reported"
+	// ClickhouseQueryActionProtected is a long-running query of a protected user, which is never killed.
+	ClickhouseQueryActionProtected = "protected"
+	// ClickhouseQueryActionWouldKill is a long-running query which a dry run would have killed.
+	ClickhouseQueryActionWouldKill = "would_kill"
+	// ClickhouseQueryActionKilled is a long-running query which was killed.
+	ClickhouseQueryActionKilled = "killed"
+	// ClickhouseQueryActionKillFailed is a long-running query which could not be killed, see Error.
+	ClickhouseQueryActionKillFailed = "kill_failed"
+)
+
+type (
+	// ClickhouseWatchdogService is a ClickHouse service watched by a ClickhouseQueryWatchdog.
+	ClickhouseWatchdogService struct {
+		Project string
+		Service string
+	}
+
+	// ClickhouseLongRunningQuery is a query running longer than its threshold.
+	ClickhouseLongRunningQuery struct {
+		ClickhouseCurrentQuery
+		Project   string
+		Service   string
+		Threshold time.Duration
+		Action    string
+		Error     error
+	}
+
+	// ClickhouseQueryWatchdog polls the current queries of ClickHouse services and reports,
+	// and optionally kills, the queries running longer than their threshold.
+	ClickhouseQueryWatchdog struct {
+		Handler  *ClickhouseQueryHandler
+		Services []ClickhouseWatchdogService
+
+		// DefaultThreshold applies to queries without a user or database threshold, disabled when zero.
+		DefaultThreshold time.Duration
+		// UserThresholds and DatabaseThresholds override DefaultThreshold, the lower one wins when both match.
+		UserThresholds     map[string]time.Duration
+		DatabaseThresholds map[string]time.Duration
+		// ProtectedUsers are the users whose queries are never killed.
+		ProtectedUsers []string
+
+		// Kill kills the long-running queries with KILL QUERY.
+		Kill bool
+		// DryRun reports the queries Kill would kill without killing them.
+		DryRun bool
+
+		// Interval is the time between two checks of Run, DefaultPollInterval when zero.
+		Interval time.Duration
+		// Concurrency is the number of services checked in parallel.
+		Concurrency int
+	}
+)
+
+// NewClickhouseQueryWatchdog creates a watchdog reporting the queries running longer than threshold.
+func NewClickhouseQueryWatchdog(h *ClickhouseQueryHandler, threshold time.Duration, services ...ClickhouseWatchdogService) *ClickhouseQueryWatchdog {
+	return &ClickhouseQueryWatchdog{
+		Handler:          h,
+		Services:         services,
+		DefaultThreshold: threshold,
+		Concurrency:      defaultInventoryConcurrency,
+	}
+}
+
+// threshold returns the threshold of a query, zero when it has none.
+func (w *ClickhouseQueryWatchdog) threshold(q ClickhouseCurrentQuery) time.Duration {
+	user, userOK := w.UserThresholds[q.User]
+	database, databaseOK := w.DatabaseThresholds[q.Database]
+	switch {
+	case userOK && databaseOK:
+		if database < user {
+			return database
+		}
+		return user
+	case userOK:
+		return user
+	case databaseOK:
+		return database
+	}
+
+	return w.DefaultThreshold
+}
+
+// protected reports whether the queries of the user must not be killed.
+func (w *ClickhouseQueryWatchdog) protected(user string) bool {
+	for _, u := range w.ProtectedUsers {
+		if u == user {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clickhouseKillQueryStatement kills the query by its ID, so that no other query is killed. The ID is not
+// part of the documented current queries response, without it the queries of the same user with the same
+// text running for at least threshold are killed, which are all long-running queries of the threshold.
+func clickhouseKillQueryStatement(q ClickhouseCurrentQuery, threshold time.Duration) (string, error) {
+	switch {
+	case q.QueryID != "":
+		return "KILL QUERY WHERE query_id = " + quoteClickhouseString(q.QueryID), nil
+	case q.User == "" || q.Query == "":
+		return "", ErrClickhouseQueryIDUnknown
+	}
+
+	return fmt.Sprintf("KILL QUERY WHERE user = %s AND query = %s AND elapsed >= %s",
+		quoteClickhouseString(q.User), quoteClickhouseString(q.Query), strconv.FormatFloat(threshold.Seconds(), 'f', -1, 64)), nil
+}
+
+// kill kills the query of the service.
+func (w *ClickhouseQueryWatchdog) kill(ctx context.Context, s ClickhouseWatchdogService, q ClickhouseCurrentQuery, threshold time.Duration) error {
+	statement, err := clickhouseKillQueryStatement(q, threshold)
+	if err != nil {
+		return err
+	}
+
+	return w.Handler.exec(ctx, s.Project, s.Service, statement)
+}
+
+// checkService checks the current queries of a service.
+func (w *ClickhouseQueryWatchdog) checkService(ctx context.Context, s ClickhouseWatchdogService) ([]ClickhouseLongRunningQuery, error) {
+	r, err := w.Handler.CurrentQueries(ctx, s.Project, s.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ClickhouseLongRunningQuery
+	for _, q := range r.Queries {
+		threshold := w.threshold(q)
+		if threshold <= 0 || time.Duration(q.Elapsed*float64(time.Second)) < threshold {
+			continue
+		}
+
+		lq := ClickhouseLongRunningQuery{
+			ClickhouseCurrentQuery: q,
+			Project:                s.Project,
+			Service:                s.Service,
+			Threshold:              threshold,
+			Action:                 ClickhouseQueryActionReported,
+		}
+
+		switch {
+		case !w.Kill:
+		case w.protected(q.User):
+			lq.Action = ClickhouseQueryActionProtected
+		case w.DryRun:
+			lq.Action = ClickhouseQueryActionWouldKill
+		default:
+			lq.Action = ClickhouseQueryActionKilled
+			if err := w.kill(ctx, s, q, threshold); err != nil {
+				lq.Action = ClickhouseQueryActionKillFailed
+				lq.Error = err
+			}
+		}
+
+		result = append(result, lq)
+	}
+
+	return result, nil
+}
+
+// Check checks all the services once and returns their long-running queries, the longest first.
+// A service which cannot be checked does not stop the others, its error is joined to the returned one.
+func (w *ClickhouseQueryWatchdog) Check(ctx context.Context) ([]ClickhouseLongRunningQuery, error) {
+	concurrency := w.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultInventoryConcurrency
+	}
+
+	var (
+		mu     sync.Mutex
+		result []ClickhouseLongRunningQuery
+		errs   []error
+	)
+
+	err := forEachConcurrently(ctx, len(w.Services), concurrency, func(ctx context.Context, i int) error {
+		s := w.Services[i]
+		queries, err := w.checkService(ctx, s)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", s.Project, s.Service, err))
+		}
+		result = append(result, queries...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Elapsed > result[j].Elapsed
+	})
+
+	return result, errors.Join(errs...)
+}
+
+// Run checks the services every Interval until ctx is done, passing the results of each check to report
+// when it is set.
+func (w *ClickhouseQueryWatchdog) Run(ctx context.Context, report func([]ClickhouseLongRunningQuery, error)) error {
+	return waitFor(ctx, w.Interval, func() (bool, error) {
+		queries, err := w.Check(ctx)
+		if report != nil {
+			report(queries, err)
+		}
+		return false, nil
+	})
+}
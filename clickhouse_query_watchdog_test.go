@@ -0,0 +1,130 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupClickhouseQueryWatchdogTestCase(t *testing.T) (*Client, func() []string, func(t *testing.T)) {
+	t.Log("setup ClickHouse Query Watchdog test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+	)
+
+	var (
+		mu    sync.Mutex
+		kills []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/userauth":
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(authResponse{Token: AccessToken, State: "active"})
+		case r.URL.Path == "/project/test-pr/service/ch-1/clickhouse/query" && r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(ClickhouseCurrentQueriesResponse{Queries: []ClickhouseCurrentQuery{
+				{QueryID: "q-'1", User: "etl", Database: "raw", Elapsed: 700.25, Query: "INSERT INTO raw.events SELECT 'x'"},
+				{QueryID: "q-2", User: "avnadmin", Database: "raw", Elapsed: 900, Query: "OPTIMIZE TABLE raw.events"},
+				{QueryID: "q-3", User: "analyst", Database: "sales", Elapsed: 90, Query: "SELECT count() FROM sales.orders"},
+				{User: "analyst", Database: "sales", Elapsed: 80, Query: "SELECT 'a', sleep(3)"},
+				{Database: "sales", Elapsed: 70},
+				{QueryID: "q-5", User: "analyst", Database: "sales", Elapsed: 10, Query: "SELECT 1"},
+			}})
+		case r.URL.Path == "/project/test-pr/service/ch-1/clickhouse/query" && r.Method == http.MethodPost:
+			var req ClickhouseQueryRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			kills = append(kills, req.Query)
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"meta": [], "data": []}`))
+		case r.URL.Path == "/project/test-pr/service/ch-2/clickhouse/query":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "permission denied"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	return c, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string(nil), kills...)
+		}, func(t *testing.T) {
+			t.Log("teardown ClickHouse Query Watchdog test case")
+			ts.Close()
+		}
+}
+
+func TestClickhouseQueryWatchdog_Check(t *testing.T) {
+	c, kills, tearDown := setupClickhouseQueryWatchdogTestCase(t)
+	defer tearDown(t)
+
+	w := NewClickhouseQueryWatchdog(c.ClickHouseQuery, 10*time.Minute, ClickhouseWatchdogService{Project: "test-pr", Service: "ch-1"})
+	w.DatabaseThresholds = map[string]time.Duration{"sales": time.Minute}
+	w.UserThresholds = map[string]time.Duration{"analyst": 5 * time.Minute}
+	w.ProtectedUsers = []string{"avnadmin"}
+	w.Kill = true
+	w.DryRun = true
+
+	ctx := context.Background()
+	got, err := w.Check(ctx)
+	require.NoError(t, err)
+	require.Len(t, got, 5)
+	assert.Equal(t, "avnadmin", got[0].User)
+	assert.Equal(t, ClickhouseQueryActionProtected, got[0].Action)
+	assert.Equal(t, ClickhouseQueryActionWouldKill, got[1].Action)
+	assert.Equal(t, 10*time.Minute, got[1].Threshold)
+	assert.Equal(t, time.Minute, got[2].Threshold)
+	assert.Equal(t, ClickhouseQueryActionWouldKill, got[3].Action)
+	assert.Empty(t, kills())
+
+	w.DryRun = false
+	w.Services = append(w.Services, ClickhouseWatchdogService{Project: "test-pr", Service: "ch-2"})
+	got, err = w.Check(ctx)
+	assert.ErrorContains(t, err, "test-pr/ch-2")
+	require.Len(t, got, 5)
+	assert.Equal(t, ClickhouseQueryActionKilled, got[1].Action)
+	assert.Equal(t, ClickhouseQueryActionKilled, got[2].Action)
+	// Queries without an ID are killed by their user and text, only past the threshold
+	assert.Equal(t, ClickhouseQueryActionKilled, got[3].Action)
+	assert.Equal(t, ClickhouseQueryActionKillFailed, got[4].Action)
+	assert.ErrorIs(t, got[4].Error, ErrClickhouseQueryIDUnknown)
+	assert.ElementsMatch(t, []string{
+		`KILL QUERY WHERE query_id = 'q-\'1'`,
+		`KILL QUERY WHERE query_id = 'q-3'`,
+		`KILL QUERY WHERE user = 'analyst' AND query = 'SELECT \'a\', sleep(3)' AND elapsed >= 60`,
+	}, kills())
+}
+
+func TestClickhouseQueryWatchdog_Run(t *testing.T) {
+	c, _, tearDown := setupClickhouseQueryWatchdogTestCase(t)
+	defer tearDown(t)
+
+	w := NewClickhouseQueryWatchdog(c.ClickHouseQuery, time.Minute, ClickhouseWatchdogService{Project: "test-pr", Service: "ch-1"})
+	w.Interval = time.Millisecond
+
+	// Without a report function the checks still run until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Run(ctx, nil), context.DeadlineExceeded)
+}
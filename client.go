@@ -90,6 +90,8 @@ type Client struct {
 	ClickhouseDatabase                 *ClickhouseDatabaseHandler
 	ClickhouseUser                     *ClickhouseUserHandler
 	ClickHouseQuery                    *ClickhouseQueryHandler
+	ClickhouseRole                     *ClickhouseRoleHandler
+	ClickhouseGrant                    *ClickhouseGrantHandler
 	ServiceTags                        *ServiceTagsHandler
 	Organization                       *OrganizationHandler
 	OrganizationUser                   *OrganizationUserHandler
@@ -99,6 +101,9 @@ type Client struct {
 	OrganizationApplicationUserHandler *OrganizationApplicationUserHandler
 	OpenSearchSecurityPluginHandler    *OpenSearchSecurityPluginHandler
 	OpenSearchACLs                     *OpenSearchACLsHandler
+	OpenSearchInternalUsers            *OpenSearchInternalUsersHandler
+	OpenSearchRoles                    *OpenSearchRolesHandler
+	OpenSearchRoleMappings             *OpenSearchRoleMappingsHandler
 	ProjectOrganization                *ProjectOrgHandler
 }
 
@@ -261,6 +266,8 @@ func (c *Client) Init() {
 	c.ClickhouseDatabase = &ClickhouseDatabaseHandler{c}
 	c.ClickhouseUser = &ClickhouseUserHandler{c}
 	c.ClickHouseQuery = &ClickhouseQueryHandler{c}
+	c.ClickhouseRole = &ClickhouseRoleHandler{c}
+	c.ClickhouseGrant = &ClickhouseGrantHandler{c}
 	c.ServiceTags = &ServiceTagsHandler{c}
 	c.Organization = &OrganizationHandler{c}
 	c.OrganizationUser = &OrganizationUserHandler{c}
@@ -270,6 +277,9 @@ func (c *Client) Init() {
 	c.OrganizationApplicationUserHandler = &OrganizationApplicationUserHandler{c}
 	c.OpenSearchSecurityPluginHandler = &OpenSearchSecurityPluginHandler{c}
 	c.OpenSearchACLs = &OpenSearchACLsHandler{c}
+	c.OpenSearchInternalUsers = &OpenSearchInternalUsersHandler{c}
+	c.OpenSearchRoles = &OpenSearchRolesHandler{c}
+	c.OpenSearchRoleMappings = &OpenSearchRoleMappingsHandler{c}
 	c.ProjectOrganization = &ProjectOrgHandler{c}
 }
 
@@ -0,0 +1,222 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrConnectionPoolsInvalid is returned when a connection pool configuration cannot be applied.
+var ErrConnectionPoolsInvalid = errors.New("invalid connection pools")
+
+// defaultConnectionPoolMode is the mode the server gives to the pools created without one.
+const defaultConnectionPoolMode = "transaction"
+
+type (
+	// ConnectionPoolSyncResult lists the names of the connection pools changed by SyncPools.
+	ConnectionPoolSyncResult struct {
+		Created []string
+		Updated []string
+		Deleted []string
+	}
+)
+
+// PgMaxConnections returns the max_connections of a PostgreSQL service, the pg.max_connections user config
+// when set, otherwise the value the service metadata reports for the plan, zero when neither is known.
+func PgMaxConnections(s *Service) int {
+	if pg, ok := s.UserConfig["pg"].(map[string]interface{}); ok {
+		if n, ok := connectionPoolInt(pg["max_connections"]); ok {
+			return n
+		}
+	}
+
+	if m, ok := s.Metadata.(map[string]interface{}); ok {
+		if n, ok := connectionPoolInt(m["max_connections"]); ok {
+			return n
+		}
+	}
+
+	return 0
+}
+
+// connectionPoolInt converts a decoded JSON number to int.
+func connectionPoolInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	}
+
+	return 0, false
+}
+
+// ValidateConnectionPools checks that the pools fit in maxConnections, when known, and only use
+// the given databases and users.
+func ValidateConnectionPools(pools []CreateConnectionPoolRequest, maxConnections int, databases, users []string) error {
+	knownDatabases := make(map[string]bool, len(databases))
+	for _, d := range databases {
+		knownDatabases[d] = true
+	}
+	knownUsers := make(map[string]bool, len(users))
+	for _, u := range users {
+		knownUsers[u] = true
+	}
+
+	var (
+		problems []string
+		total    int
+		names    = make(map[string]bool, len(pools))
+	)
+	for _, p := range pools {
+		if names[p.PoolName] {
+			problems = append(problems, fmt.Sprintf("pool %s is defined more than once", p.PoolName))
+		}
+		names[p.PoolName] = true
+
+		if p.PoolSize <= 0 {
+			problems = append(problems, fmt.Sprintf("pool %s has a non-positive size %d", p.PoolName, p.PoolSize))
+		}
+		if !knownDatabases[p.Database] {
+			problems = append(problems, fmt.Sprintf("pool %s uses unknown database %s", p.PoolName, p.Database))
+		}
+		if p.Username != nil && !knownUsers[*p.Username] {
+			problems = append(problems, fmt.Sprintf("pool %s uses unknown user %s", p.PoolName, *p.Username))
+		}
+		total += p.PoolSize
+	}
+
+	if maxConnections > 0 && total > maxConnections {
+		problems = append(problems, fmt.Sprintf("total pool size %d exceeds max_connections %d", total, maxConnections))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrConnectionPoolsInvalid, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// validate validates the pools against the service and its databases.
+func (h *ConnectionPoolsHandler) validate(ctx context.Context, project, serviceName string, service *Service, pools []CreateConnectionPoolRequest) error {
+	databases, err := h.client.Databases.List(ctx, project, serviceName)
+	if err != nil {
+		return err
+	}
+
+	databaseNames := make([]string, len(databases))
+	for i, d := range databases {
+		databaseNames[i] = d.DatabaseName
+	}
+	userNames := make([]string, len(service.Users))
+	for i, u := range service.Users {
+		userNames[i] = u.Username
+	}
+
+	return ValidateConnectionPools(pools, PgMaxConnections(service), databaseNames, userNames)
+}
+
+// Validate checks that the pools fit in the max_connections of the service and only use its databases and users.
+func (h *ConnectionPoolsHandler) Validate(ctx context.Context, project, serviceName string, pools []CreateConnectionPoolRequest) error {
+	service, err := h.client.Services.Get(ctx, project, serviceName)
+	if err != nil {
+		return err
+	}
+
+	return h.validate(ctx, project, serviceName, service, pools)
+}
+
+// connectionPoolMode returns the mode of a pool, the server default when empty.
+func connectionPoolMode(mode string) string {
+	if mode == "" {
+		return defaultConnectionPoolMode
+	}
+
+	return mode
+}
+
+// connectionPoolChanged reports whether the pool differs from the desired one.
+func connectionPoolChanged(current *ConnectionPool, desired CreateConnectionPoolRequest) bool {
+	return current.Database != desired.Database ||
+		connectionPoolMode(current.PoolMode) != connectionPoolMode(desired.PoolMode) ||
+		current.PoolSize != desired.PoolSize ||
+		current.Username != PointerToString(desired.Username)
+}
+
+// SyncPools validates the desired pools and makes the pools of the service match them,
+// deleting first to free connections, then updating and creating.
+func (h *ConnectionPoolsHandler) SyncPools(ctx context.Context, project, serviceName string, desired []CreateConnectionPoolRequest) (*ConnectionPoolSyncResult, error) {
+	service, err := h.client.Services.Get(ctx, project, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.validate(ctx, project, serviceName, service, desired); err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]*ConnectionPool, len(service.ConnectionPools))
+	for _, p := range service.ConnectionPools {
+		current[p.PoolName] = p
+	}
+	wanted := make(map[string]bool, len(desired))
+	for _, p := range desired {
+		wanted[p.PoolName] = true
+	}
+
+	result := &ConnectionPoolSyncResult{}
+	for name := range current {
+		if !wanted[name] {
+			result.Deleted = append(result.Deleted, name)
+		}
+	}
+	sort.Strings(result.Deleted)
+
+	for _, name := range result.Deleted {
+		if err := h.Delete(ctx, project, serviceName, name); err != nil {
+			return result, err
+		}
+	}
+
+	sorted := append([]CreateConnectionPoolRequest(nil), desired...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].PoolName < sorted[j].PoolName
+	})
+
+	for _, p := range sorted {
+		c, ok := current[p.PoolName]
+		if !ok || !connectionPoolChanged(c, p) {
+			continue
+		}
+
+		_, err := h.Update(ctx, project, serviceName, p.PoolName, UpdateConnectionPoolRequest{
+			Database: p.Database,
+			PoolMode: p.PoolMode,
+			PoolSize: p.PoolSize,
+			Username: p.Username,
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Updated = append(result.Updated, p.PoolName)
+	}
+
+	for _, p := range sorted {
+		if _, ok := current[p.PoolName]; ok {
+			continue
+		}
+
+		if _, err := h.Create(ctx, project, serviceName, p); err != nil {
+			return result, err
+		}
+		result.Created = append(result.Created, p.PoolName)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,142 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConnectionPools(t *testing.T) {
+	pools := []CreateConnectionPoolRequest{
+		{PoolName: "app", Database: "defaultdb", PoolMode: "transaction", PoolSize: 40, Username: ToStringPointer("app")},
+		{PoolName: "reports", Database: "analytics", PoolMode: "session", PoolSize: 30},
+	}
+
+	assert.NoError(t, ValidateConnectionPools(pools, 100, []string{"defaultdb", "analytics"}, []string{"app"}))
+	assert.NoError(t, ValidateConnectionPools(pools, 0, []string{"defaultdb", "analytics"}, []string{"app"}))
+
+	err := ValidateConnectionPools(
+		append(pools, CreateConnectionPoolRequest{PoolName: "app", Database: "defaultdb", PoolSize: 0}),
+		60, []string{"defaultdb"}, nil,
+	)
+	assert.ErrorIs(t, err, ErrConnectionPoolsInvalid)
+	assert.EqualError(t, err, "invalid connection pools: "+
+		"pool app uses unknown user app; "+
+		"pool reports uses unknown database analytics; "+
+		"pool app is defined more than once; "+
+		"pool app has a non-positive size 0; "+
+		"total pool size 70 exceeds max_connections 60")
+}
+
+func TestPgMaxConnections(t *testing.T) {
+	assert.Equal(t, 0, PgMaxConnections(&Service{}))
+	assert.Equal(t, 100, PgMaxConnections(&Service{Metadata: map[string]interface{}{"max_connections": json.Number("100")}}))
+	assert.Equal(t, 250, PgMaxConnections(&Service{
+		Metadata:   map[string]interface{}{"max_connections": json.Number("100")},
+		UserConfig: map[string]interface{}{"pg": map[string]interface{}{"max_connections": json.Number("250")}},
+	}))
+}
+
+func TestConnectionPoolsHandler_SyncPools(t *testing.T) {
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+		servicePath  = "/project/test-pr/service/test-sr"
+	)
+
+	var requests []string
+	pools := map[string]ConnectionPool{
+		"app":  {PoolName: "app", Database: "defaultdb", PoolMode: "transaction", PoolSize: 10, Username: "app"},
+		"old":  {PoolName: "old", Database: "defaultdb", PoolMode: "session", PoolSize: 10},
+		"same": {PoolName: "same", Database: "defaultdb", PoolMode: "session", PoolSize: 5},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch r.URL.Path {
+		case "/userauth":
+			_ = json.NewEncoder(w).Encode(authResponse{Token: AccessToken, State: "active"})
+			return
+		case servicePath:
+			s := &Service{
+				Name:     "test-sr",
+				Metadata: map[string]interface{}{"max_connections": 100},
+				Users:    []*ServiceUser{{Username: "avnadmin"}, {Username: "app"}},
+			}
+			for _, p := range pools {
+				p := p
+				s.ConnectionPools = append(s.ConnectionPools, &p)
+			}
+			_ = json.NewEncoder(w).Encode(ServiceResponse{Service: s})
+			return
+		case servicePath + "/db":
+			_, _ = w.Write([]byte(`{"databases": [{"database_name": "defaultdb"}]}`))
+			return
+		}
+
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		name := strings.TrimPrefix(r.URL.Path, servicePath+"/connection_pool/")
+		switch r.Method {
+		case http.MethodPost:
+			var req CreateConnectionPoolRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.PoolMode == "" {
+				req.PoolMode = "transaction"
+			}
+			pools[req.PoolName] = ConnectionPool{PoolName: req.PoolName, Database: req.Database, PoolMode: req.PoolMode, PoolSize: req.PoolSize, Username: PointerToString(req.Username)}
+		case http.MethodPut:
+			var req UpdateConnectionPoolRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			pools[name] = ConnectionPool{PoolName: name, Database: req.Database, PoolMode: req.PoolMode, PoolSize: req.PoolSize, Username: PointerToString(req.Username)}
+		case http.MethodDelete:
+			delete(pools, name)
+		}
+		_, _ = w.Write([]byte(`{"message": "ok"}`))
+	}))
+	defer ts.Close()
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	desired := []CreateConnectionPoolRequest{
+		{PoolName: "new", Database: "defaultdb", PoolSize: 20},
+		{PoolName: "app", Database: "defaultdb", PoolMode: "transaction", PoolSize: 50, Username: ToStringPointer("app")},
+		{PoolName: "same", Database: "defaultdb", PoolMode: "session", PoolSize: 5},
+	}
+
+	got, err := c.ConnectionPools.SyncPools(ctx, "test-pr", "test-sr", desired)
+	require.NoError(t, err)
+	assert.Equal(t, &ConnectionPoolSyncResult{Created: []string{"new"}, Updated: []string{"app"}, Deleted: []string{"old"}}, got)
+	assert.Equal(t, []string{
+		"DELETE " + servicePath + "/connection_pool/old",
+		"PUT " + servicePath + "/connection_pool/app",
+		"POST " + servicePath + "/connection_pool",
+	}, requests)
+
+	assert.Len(t, pools, 3)
+	assert.Equal(t, 50, pools["app"].PoolSize)
+	assert.Equal(t, "transaction", pools["new"].PoolMode)
+
+	// A second sync changes nothing, the server default pool mode matches the empty one
+	requests = nil
+	got, err = c.ConnectionPools.SyncPools(ctx, "test-pr", "test-sr", desired)
+	require.NoError(t, err)
+	assert.Equal(t, &ConnectionPoolSyncResult{}, got)
+	assert.Empty(t, requests)
+
+	requests = nil
+	desired[0].PoolSize = 60
+	_, err = c.ConnectionPools.SyncPools(ctx, "test-pr", "test-sr", desired)
+	assert.ErrorIs(t, err, ErrConnectionPoolsInvalid)
+	assert.Empty(t, requests)
+}
@@ -2,6 +2,7 @@ package aiven
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 )
 
@@ -45,11 +46,27 @@ type (
 
 	flinkApplicationQueryFull struct {
 		flinkApplicationQueryBase
-		Rows []struct {
-			Data  interface{} `json:"data"`
-			Index int         `json:"index"`
-			Kind  string      `json:"kind"`
-		} `json:"rows"`
+		Rows []FlinkQueryRow `json:"rows"`
+	}
+
+	// FlinkQueryRow is a changelog row of a Flink query result, decode it with DecodeFlinkQueryRow
+	FlinkQueryRow struct {
+		Data  interface{} `json:"data"`
+		Index int         `json:"index"`
+		Kind  string      `json:"kind"`
+
+		// data keeps the raw data for lossless decoding
+		data json.RawMessage
+	}
+
+	// FlinkQueryColumn is a column of a Flink query result
+	FlinkQueryColumn struct {
+		DataType  string `json:"data_type"`
+		Extras    string `json:"extras"`
+		Key       string `json:"key"`
+		Name      string `json:"name"`
+		Nullable  bool   `json:"nullable"`
+		Watermark string `json:"watermark"`
 	}
 
 	// ListFlinkApplicationQueryResponse Aiven API response
@@ -61,19 +78,12 @@ type (
 
 	// shared fields by some responses
 	flinkApplicationQueryBase struct {
-		Columns []struct {
-			DataType  string `json:"data_type"`
-			Extras    string `json:"extras"`
-			Key       string `json:"key"`
-			Name      string `json:"name"`
-			Nullable  bool   `json:"nullable"`
-			Watermark string `json:"watermark"`
-		} `json:"columns"`
-		CreateTime    *time.Time `json:"create_time"`
-		JobExpireTime *time.Time `json:"job_expire_time"`
-		JobID         string     `json:"job_id"`
-		JobName       string     `json:"job_name"`
-		QueryID       string     `json:"query_id"`
+		Columns       []FlinkQueryColumn `json:"columns"`
+		CreateTime    *time.Time         `json:"create_time"`
+		JobExpireTime *time.Time         `json:"job_expire_time"`
+		JobID         string             `json:"job_id"`
+		JobName       string             `json:"job_name"`
+		QueryID       string             `json:"query_id"`
 		QueryParams   struct {
 			JobTTL  int `json:"job_ttl"`
 			MaxRows int `json:"max_rows"`
@@ -100,6 +110,25 @@ type (
 	}
 )
 
+// UnmarshalJSON keeps the raw data of the row next to its generic value
+func (r *FlinkQueryRow) UnmarshalJSON(b []byte) error {
+	type row FlinkQueryRow
+	raw := struct {
+		row
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*r = FlinkQueryRow(raw.row)
+	r.data = raw.Data
+	if len(raw.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw.Data, &r.Data)
+}
+
 // Create creates a Flink query
 func (h *FlinkApplicationQueryHandler) Create(ctx context.Context, project, service, applicationId string, req CreateFlinkApplicationQueryRequest) (*CreateFlinkApplicationQueryResponse, error) {
 	path := buildPath("project", project, "service", service, "flink", "application", applicationId, "query")
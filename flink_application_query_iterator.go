@@ -0,0 +1,334 @@
+package aiven
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FlinkQueryRowKindInsert is an inserted row.
+	FlinkQueryRowKindInsert = "+I"
+	// FlinkQueryRowKindUpdateBefore retracts the previous value of an updated row.
+	FlinkQueryRowKindUpdateBefore = "-U"
+	// FlinkQueryRowKindUpdateAfter is the new value of an updated row.
+	FlinkQueryRowKindUpdateAfter = "+U"
+	// FlinkQueryRowKindDelete is a deleted row.
+	FlinkQueryRowKindDelete = "-D"
+)
+
+// flinkQueryTimeLayouts are the layouts Flink formats timestamps with.
+var flinkQueryTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02",
+}
+
+type (
+	// FlinkQueryRecord is a decoded row of a Flink query result, Values are in the order of the columns.
+	FlinkQueryRecord struct {
+		Index  int
+		Kind   string
+		Values []interface{}
+	}
+
+	// FlinkQueryIterator polls the rows of a Flink query as its job progresses.
+	// Close it to cancel the job.
+	FlinkQueryIterator struct {
+		handler       *FlinkApplicationQueryHandler
+		project       string
+		service       string
+		applicationID string
+		// QueryID is the ID of the created query.
+		QueryID string
+
+		interval time.Duration
+		maxRows  int
+		columns  []FlinkQueryColumn
+		expire   *time.Time
+		fetched  bool
+		next     int
+		count    int
+		pending  []FlinkQueryRecord
+		current  FlinkQueryRecord
+		snapshot [][]interface{}
+		done     bool
+		err      error
+
+		closeOnce sync.Once
+		closeErr  error
+	}
+)
+
+// IsRetraction reports whether the row retracts a previously emitted row.
+func (r FlinkQueryRow) IsRetraction() bool {
+	return r.Kind == FlinkQueryRowKindUpdateBefore || r.Kind == FlinkQueryRowKindDelete
+}
+
+// IsRetraction reports whether the record retracts a previously emitted record.
+func (r FlinkQueryRecord) IsRetraction() bool {
+	return r.Kind == FlinkQueryRowKindUpdateBefore || r.Kind == FlinkQueryRowKindDelete
+}
+
+// flinkQueryBaseType returns the type name of a Flink data type, e.g. DECIMAL for "DECIMAL(10, 2) NOT NULL".
+func flinkQueryBaseType(dataType string) string {
+	t := strings.ToUpper(strings.TrimSpace(dataType))
+	if i := strings.IndexAny(t, "(< "); i >= 0 {
+		t = t[:i]
+	}
+
+	return t
+}
+
+// decodeFlinkQueryValue converts a JSON value to the Go value of the Flink data type:
+// integers to int64, approximate numerics to float64, DECIMAL to its exact string,
+// BOOLEAN to bool, TIMESTAMP and DATE to time.Time, BYTES to []byte.
+// Other types, e.g. ARRAY, MAP or ROW, are decoded generically.
+func decodeFlinkQueryValue(c FlinkQueryColumn, raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	switch flinkQueryBaseType(c.DataType) {
+	case "TINYINT", "SMALLINT", "INT", "INTEGER", "BIGINT":
+		var n json.Number
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return nil, err
+		}
+		return n.Int64()
+	case "FLOAT", "DOUBLE", "REAL":
+		var f float64
+		err := json.Unmarshal(raw, &f)
+		return f, err
+	case "DECIMAL", "DEC", "NUMERIC":
+		var n json.Number
+		err := json.Unmarshal(raw, &n)
+		return n.String(), err
+	case "BOOLEAN":
+		var b bool
+		err := json.Unmarshal(raw, &b)
+		return b, err
+	case "TIMESTAMP", "TIMESTAMP_LTZ", "DATE":
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		for _, layout := range flinkQueryTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("cannot parse %s value %q", c.DataType, s)
+	case "BYTES", "BINARY", "VARBINARY":
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(s)
+	case "CHAR", "VARCHAR", "STRING", "TIME":
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	err := d.Decode(&v)
+	return v, err
+}
+
+// DecodeFlinkQueryRow decodes the data of a row into the Go values of the columns.
+// The data is either a list of values in the column order or an object keyed by column name.
+func DecodeFlinkQueryRow(columns []FlinkQueryColumn, row FlinkQueryRow) (FlinkQueryRecord, error) {
+	r := FlinkQueryRecord{Index: row.Index, Kind: row.Kind, Values: make([]interface{}, len(columns))}
+
+	raw := row.data
+	if raw == nil {
+		b, err := json.Marshal(row.Data)
+		if err != nil {
+			return r, err
+		}
+		raw = b
+	}
+
+	var values []json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		var byName map[string]json.RawMessage
+		if errM := json.Unmarshal(raw, &byName); errM != nil {
+			return r, fmt.Errorf("row %d: data is neither a list nor an object: %w", row.Index, err)
+		}
+		values = make([]json.RawMessage, len(columns))
+		for i, c := range columns {
+			values[i] = byName[c.Name]
+		}
+	}
+
+	if len(values) != len(columns) {
+		return r, fmt.Errorf("row %d: %d values for %d columns", row.Index, len(values), len(columns))
+	}
+
+	for i, c := range columns {
+		v, err := decodeFlinkQueryValue(c, values[i])
+		if err != nil {
+			return r, fmt.Errorf("row %d, column %s: %w", row.Index, c.Name, err)
+		}
+		r.Values[i] = v
+	}
+
+	return r, nil
+}
+
+// Query creates a Flink query and returns an iterator over its rows, which are polled every interval,
+// DefaultPollInterval when zero, until MaxRows rows are read or the job expires after JobTTL.
+func (h *FlinkApplicationQueryHandler) Query(
+	ctx context.Context,
+	project, service, applicationID string,
+	req CreateFlinkApplicationQueryRequest,
+	interval time.Duration,
+) (*FlinkQueryIterator, error) {
+	q, err := h.Create(ctx, project, service, applicationID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	return &FlinkQueryIterator{
+		handler:       h,
+		project:       project,
+		service:       service,
+		applicationID: applicationID,
+		QueryID:       q.QueryID,
+		interval:      interval,
+		maxRows:       req.MaxRows,
+	}, nil
+}
+
+// fetch gets the query and queues the rows not seen yet.
+func (it *FlinkQueryIterator) fetch(ctx context.Context) error {
+	q, err := it.handler.Get(ctx, it.project, it.service, it.applicationID, it.QueryID)
+	if err != nil {
+		return err
+	}
+
+	it.fetched = true
+	it.columns = q.Columns
+	it.expire = q.JobExpireTime
+
+	for _, row := range q.Rows {
+		if row.Index < it.next {
+			continue
+		}
+
+		r, err := DecodeFlinkQueryRow(it.columns, row)
+		if err != nil {
+			return err
+		}
+		it.pending = append(it.pending, r)
+		it.next = row.Index + 1
+	}
+
+	return nil
+}
+
+// Next waits for the next row, returns false when the query is done or failed, see Err.
+func (it *FlinkQueryIterator) Next(ctx context.Context) bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+
+		if len(it.pending) > 0 && (it.maxRows <= 0 || it.count < it.maxRows) {
+			it.current, it.pending = it.pending[0], it.pending[1:]
+			it.count++
+			it.apply(it.current)
+			return true
+		}
+
+		if it.done || it.maxRows > 0 && it.count >= it.maxRows {
+			it.done = true
+			return false
+		}
+
+		if it.fetched {
+			if it.expire != nil && time.Now().After(*it.expire) {
+				it.done = true
+				return false
+			}
+
+			select {
+			case <-ctx.Done():
+				it.err = ctx.Err()
+				return false
+			case <-time.After(it.interval):
+			}
+		}
+
+		before := it.next
+		if err := it.fetch(ctx); err != nil {
+			it.err = err
+			return false
+		}
+
+		// The expired job produces no more rows
+		if it.next == before && it.expire != nil && time.Now().After(*it.expire) {
+			it.done = true
+		}
+	}
+}
+
+// apply applies the changelog record to the snapshot.
+func (it *FlinkQueryIterator) apply(r FlinkQueryRecord) {
+	if !r.IsRetraction() {
+		it.snapshot = append(it.snapshot, r.Values)
+		return
+	}
+
+	for i, values := range it.snapshot {
+		if reflect.DeepEqual(values, r.Values) {
+			it.snapshot = append(it.snapshot[:i], it.snapshot[i+1:]...)
+			return
+		}
+	}
+}
+
+// Row returns the current row.
+func (it *FlinkQueryIterator) Row() FlinkQueryRecord {
+	return it.current
+}
+
+// Columns returns the columns of the result, known after the first call to Next.
+func (it *FlinkQueryIterator) Columns() []FlinkQueryColumn {
+	return it.columns
+}
+
+// Snapshot returns the current result with the retracted rows removed.
+func (it *FlinkQueryIterator) Snapshot() [][]interface{} {
+	return append([][]interface{}(nil), it.snapshot...)
+}
+
+// Err returns the error which stopped the iteration.
+func (it *FlinkQueryIterator) Err() error {
+	return it.err
+}
+
+// Close cancels the job of the query.
+func (it *FlinkQueryIterator) Close(ctx context.Context) error {
+	it.closeOnce.Do(func() {
+		it.done = true
+		_, it.closeErr = it.handler.CancelJob(ctx, it.project, it.service, it.applicationID, it.QueryID)
+	})
+
+	return it.closeErr
+}
@@ -0,0 +1,145 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeFlinkQueryRow(t *testing.T) {
+	columns := []FlinkQueryColumn{
+		{Name: "id", DataType: "BIGINT NOT NULL"},
+		{Name: "price", DataType: "DECIMAL(10, 2)"},
+		{Name: "ts", DataType: "TIMESTAMP(3) *ROWTIME*"},
+		{Name: "name", DataType: "STRING"},
+		{Name: "tags", DataType: "ARRAY<STRING>"},
+	}
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "list",
+			data: `{"index": 3, "kind": "+U", "data": [9007199254740993, 10.10, "2024-01-02 03:04:05.678", null, ["a"]]}`,
+		},
+		{
+			name: "object",
+			data: `{"index": 3, "kind": "+U", "data": {"id": 9007199254740993, "price": 10.10, "ts": "2024-01-02T03:04:05.678Z", "tags": ["a"]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var row FlinkQueryRow
+			require.NoError(t, json.Unmarshal([]byte(tt.data), &row))
+
+			got, err := DecodeFlinkQueryRow(columns, row)
+			require.NoError(t, err)
+			assert.Equal(t, FlinkQueryRecord{
+				Index: 3,
+				Kind:  FlinkQueryRowKindUpdateAfter,
+				Values: []interface{}{
+					int64(9007199254740993),
+					"10.10",
+					time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC),
+					nil,
+					[]interface{}{"a"},
+				},
+			}, got)
+		})
+	}
+
+	_, err := DecodeFlinkQueryRow(columns, FlinkQueryRow{Data: []interface{}{1}})
+	assert.Error(t, err)
+}
+
+func setupFlinkQueryIteratorTestCase(t *testing.T) (*Client, *bool, func(t *testing.T)) {
+	t.Log("setup Flink Query Iterator test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+		queryPath    = "/project/test-pr/service/test-sr/flink/application/app-1/query"
+	)
+
+	canceled := false
+	polls := 0
+	rows := []string{
+		`{"index": 0, "kind": "+I", "data": ["a", 1]}`,
+		`{"index": 1, "kind": "-U", "data": ["a", 1]}`,
+		`{"index": 2, "kind": "+U", "data": ["a", 2]}`,
+		`{"index": 3, "kind": "+I", "data": ["b", 1]}`,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch r.URL.Path {
+		case "/userauth":
+			_ = json.NewEncoder(w).Encode(authResponse{Token: AccessToken, State: "active"})
+		case queryPath:
+			_, _ = w.Write([]byte(`{"query_id": "q-1"}`))
+		case queryPath + "/q-1":
+			// Every poll returns the rows produced so far
+			polls++
+			n := 2 * polls
+			if n > len(rows) {
+				n = len(rows)
+			}
+			rsp := `{"query_id": "q-1", "columns": [{"name": "word", "data_type": "STRING"}, {"name": "cnt", "data_type": "BIGINT"}], "rows": [`
+			for i, row := range rows[:n] {
+				if i > 0 {
+					rsp += ","
+				}
+				rsp += row
+			}
+			_, _ = w.Write([]byte(rsp + "]}"))
+		case queryPath + "/q-1/cancel_job":
+			canceled = true
+			_, _ = w.Write([]byte(`{"canceled": true}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	return c, &canceled, func(t *testing.T) {
+		t.Log("teardown Flink Query Iterator test case")
+		ts.Close()
+	}
+}
+
+func TestFlinkApplicationQueryHandler_Query(t *testing.T) {
+	c, canceled, tearDown := setupFlinkQueryIteratorTestCase(t)
+	defer tearDown(t)
+
+	ctx := context.Background()
+	it, err := c.FlinkApplicationQueries.Query(ctx, "test-pr", "test-sr", "app-1", CreateFlinkApplicationQueryRequest{MaxRows: 4}, time.Millisecond)
+	require.NoError(t, err)
+
+	var kinds []string
+	for it.Next(ctx) {
+		kinds = append(kinds, it.Row().Kind)
+	}
+	require.NoError(t, it.Err())
+
+	assert.Equal(t, []string{"+I", "-U", "+U", "+I"}, kinds)
+	assert.Equal(t, [][]interface{}{{"a", int64(2)}, {"b", int64(1)}}, it.Snapshot())
+	assert.Equal(t, "cnt", it.Columns()[1].Name)
+
+	require.NoError(t, it.Close(ctx))
+	assert.True(t, *canceled)
+}
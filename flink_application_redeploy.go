@@ -0,0 +1,221 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	FlinkApplicationDeploymentStatusInitializing  = "INITIALIZING"
+	FlinkApplicationDeploymentStatusCreated       = "CREATED"
+	FlinkApplicationDeploymentStatusRunning       = "RUNNING"
+	FlinkApplicationDeploymentStatusFailing       = "FAILING"
+	FlinkApplicationDeploymentStatusFailed        = "FAILED"
+	FlinkApplicationDeploymentStatusSaving        = "SAVING"
+	FlinkApplicationDeploymentStatusSavingAndStop = "SAVING_AND_STOP"
+	FlinkApplicationDeploymentStatusCancelling    = "CANCELLING"
+	FlinkApplicationDeploymentStatusCanceled      = "CANCELED"
+	FlinkApplicationDeploymentStatusFinished      = "FINISHED"
+	FlinkApplicationDeploymentStatusRestarting    = "RESTARTING"
+	FlinkApplicationDeploymentStatusSuspended     = "SUSPENDED"
+)
+
+var (
+	// ErrFlinkApplicationVersionInvalid is returned when the new version does not validate.
+	ErrFlinkApplicationVersionInvalid = errors.New("flink application version is invalid")
+	// ErrFlinkApplicationDeploymentFailed is returned when a deployment ends in the FAILED status.
+	ErrFlinkApplicationDeploymentFailed = errors.New("flink application deployment failed")
+	// ErrFlinkApplicationNotRedeployable is returned when the current deployment is neither RUNNING
+	// nor stopped with a savepoint.
+	ErrFlinkApplicationNotRedeployable = errors.New("flink application cannot be redeployed")
+)
+
+type (
+	// FlinkApplicationRedeployRequest describes the new version to roll out.
+	FlinkApplicationRedeployRequest struct {
+		Version GenericFlinkApplicationVersionRequest
+		// Parallelism overrides the parallelism of the current deployment when set.
+		Parallelism int
+		// PollInterval defaults to DefaultPollInterval.
+		PollInterval time.Duration
+	}
+
+	// FlinkApplicationRedeployResult reports what the redeployment did.
+	FlinkApplicationRedeployResult struct {
+		Version *DetailedFlinkApplicationVersionResponse
+		// Previous is the deployment which was replaced, nil when the application was not deployed.
+		Previous *FlinkApplicationDeployment
+		// Savepoint is the savepoint the new deployment started from.
+		Savepoint  string
+		Deployment *FlinkApplicationDeployment
+		// Rollback is the deployment of the previous version started when the new one failed,
+		// only when the previous deployment was RUNNING.
+		Rollback *FlinkApplicationDeployment
+	}
+)
+
+// waitForDeployment polls the deployment until done reports true.
+func (h *FlinkApplicationDeploymentHandler) waitForDeployment(
+	ctx context.Context,
+	project, service, applicationID, deploymentID string,
+	interval time.Duration,
+	done func(d *FlinkApplicationDeployment) (bool, error),
+) (*FlinkApplicationDeployment, error) {
+	var d *FlinkApplicationDeployment
+	err := waitFor(ctx, interval, func() (bool, error) {
+		r, err := h.Get(ctx, project, service, applicationID, deploymentID)
+		if err != nil {
+			return false, err
+		}
+
+		d = &r.FlinkApplicationDeployment
+		return done(d)
+	})
+
+	return d, err
+}
+
+// waitForRunning waits until the deployment is RUNNING, a FAILED deployment is an error.
+func (h *FlinkApplicationDeploymentHandler) waitForRunning(
+	ctx context.Context,
+	project, service, applicationID, deploymentID string,
+	interval time.Duration,
+) (*FlinkApplicationDeployment, error) {
+	return h.waitForDeployment(ctx, project, service, applicationID, deploymentID, interval, func(d *FlinkApplicationDeployment) (bool, error) {
+		switch d.Status {
+		case FlinkApplicationDeploymentStatusRunning:
+			return true, nil
+		case FlinkApplicationDeploymentStatusFailed:
+			return false, fmt.Errorf("%w: deployment %s", ErrFlinkApplicationDeploymentFailed, d.ID)
+		}
+
+		return false, nil
+	})
+}
+
+// StopWithSavepoint stops the deployment and waits for its savepoint.
+func (h *FlinkApplicationDeploymentHandler) StopWithSavepoint(
+	ctx context.Context,
+	project, service, applicationID, deploymentID string,
+	interval time.Duration,
+) (*FlinkApplicationDeployment, error) {
+	if _, err := h.Stop(ctx, project, service, applicationID, deploymentID); err != nil {
+		return nil, err
+	}
+
+	return h.waitForDeployment(ctx, project, service, applicationID, deploymentID, interval, func(d *FlinkApplicationDeployment) (bool, error) {
+		switch d.Status {
+		case FlinkApplicationDeploymentStatusFinished, FlinkApplicationDeploymentStatusCanceled:
+			if d.LastSavepoint == "" {
+				return false, fmt.Errorf("deployment %s stopped without a savepoint", d.ID)
+			}
+			return true, nil
+		case FlinkApplicationDeploymentStatusFailed:
+			return false, fmt.Errorf("%w: deployment %s failed while taking a savepoint", ErrFlinkApplicationDeploymentFailed, d.ID)
+		}
+
+		return false, nil
+	})
+}
+
+// Redeploy rolls out a new version of the application: the version is validated and created,
+// the current deployment is stopped with a savepoint and a new deployment is started from it
+// with the same parallelism. A deployment which already ended with a savepoint is not stopped,
+// any other status than RUNNING is refused with ErrFlinkApplicationNotRedeployable. When the new
+// deployment fails the returned error wraps ErrFlinkApplicationDeploymentFailed, and a previous
+// version which was RUNNING is deployed again from the same savepoint.
+func (h *FlinkApplicationDeploymentHandler) Redeploy(
+	ctx context.Context,
+	project, service, applicationID string,
+	req FlinkApplicationRedeployRequest,
+) (*FlinkApplicationRedeployResult, error) {
+	versions := h.client.FlinkApplicationVersions
+
+	v, err := versions.Validate(ctx, project, service, applicationID, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Message != "" {
+		return nil, fmt.Errorf("%w: line %d, column %d: %s",
+			ErrFlinkApplicationVersionInvalid, v.Position.LineNumber, v.Position.CharacterNumber, v.Message)
+	}
+
+	app, err := h.client.FlinkApplications.Get(ctx, project, service, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a running deployment can be rolled back to, the state of any other one is unknown
+	current := app.CurrentDeployment
+	running := current.Status == FlinkApplicationDeploymentStatusRunning
+	if current.ID != "" && !running {
+		switch current.Status {
+		case FlinkApplicationDeploymentStatusFinished,
+			FlinkApplicationDeploymentStatusCanceled,
+			FlinkApplicationDeploymentStatusFailed:
+			if current.LastSavepoint == "" {
+				return nil, fmt.Errorf("%w: deployment %s is %s without a savepoint",
+					ErrFlinkApplicationNotRedeployable, current.ID, current.Status)
+			}
+		default:
+			return nil, fmt.Errorf("%w: deployment %s is %s", ErrFlinkApplicationNotRedeployable, current.ID, current.Status)
+		}
+	}
+
+	result := new(FlinkApplicationRedeployResult)
+	if result.Version, err = versions.Create(ctx, project, service, applicationID, req.Version); err != nil {
+		return nil, err
+	}
+
+	deployment := CreateFlinkApplicationDeploymentRequest{
+		Parallelism: req.Parallelism,
+		VersionID:   result.Version.ID,
+	}
+
+	if current.ID != "" {
+		previous := &current
+		if running {
+			previous, err = h.StopWithSavepoint(ctx, project, service, applicationID, current.ID, req.PollInterval)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		result.Previous = previous
+		result.Savepoint = previous.LastSavepoint
+		deployment.StartingSavepoint = previous.LastSavepoint
+		deployment.RestartEnabled = previous.RestartEnabled
+		if deployment.Parallelism == 0 {
+			deployment.Parallelism = previous.Parallelism
+		}
+	}
+
+	created, err := h.Create(ctx, project, service, applicationID, deployment)
+	if err != nil {
+		return result, err
+	}
+
+	result.Deployment, err = h.waitForRunning(ctx, project, service, applicationID, created.ID, req.PollInterval)
+	if err == nil || !errors.Is(err, ErrFlinkApplicationDeploymentFailed) || !running {
+		return result, err
+	}
+
+	rollback := deployment
+	rollback.VersionID = result.Previous.VersionID
+	rollback.Parallelism = result.Previous.Parallelism
+
+	created, errR := h.Create(ctx, project, service, applicationID, rollback)
+	if errR != nil {
+		return result, fmt.Errorf("%w, rolling back: %s", err, errR)
+	}
+
+	result.Rollback, errR = h.waitForRunning(ctx, project, service, applicationID, created.ID, req.PollInterval)
+	if errR != nil {
+		return result, fmt.Errorf("%w, rolling back: %s", err, errR)
+	}
+
+	return result, fmt.Errorf("%w, rolled back to version %s", err, rollback.VersionID)
+}
@@ -0,0 +1,178 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFlinkRedeployTestCase(t *testing.T, current FlinkApplicationDeployment, newStatus string) (*Client, *[]CreateFlinkApplicationDeploymentRequest, func(t *testing.T)) {
+	t.Log("setup Flink Redeploy test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+		appPath      = "/project/test-pr/service/test-sr/flink/application/app-1"
+	)
+
+	var created []CreateFlinkApplicationDeploymentRequest
+	stopped := false
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		var rsp interface{}
+		switch r.URL.Path {
+		case "/userauth":
+			rsp = authResponse{Token: AccessToken, State: "active"}
+		case appPath + "/version/validate":
+			rsp = ValidateFlinkApplicationVersionResponse{}
+		case appPath + "/version":
+			rsp = DetailedFlinkApplicationVersionResponse{ID: "v-2", Version: 2}
+		case appPath:
+			rsp = DetailedFlinkApplicationResponse{CurrentDeployment: current}
+		case appPath + "/deployment/d-1/stop":
+			stopped = true
+			rsp = StopFlinkApplicationDeploymentResponse{}
+		case appPath + "/deployment/d-1":
+			d := FlinkApplicationDeployment{ID: "d-1", VersionID: "v-1", Parallelism: 3, Status: FlinkApplicationDeploymentStatusSavingAndStop}
+			if stopped {
+				d.Status = FlinkApplicationDeploymentStatusFinished
+				d.LastSavepoint = "s3://savepoints/sp-1"
+			}
+			rsp = GetFlinkApplicationDeploymentResponse{FlinkApplicationDeployment: d}
+		case appPath + "/deployment":
+			var req CreateFlinkApplicationDeploymentRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+			created = append(created, req)
+			rsp = CreateFlinkApplicationDeploymentResponse{FlinkApplicationDeployment: FlinkApplicationDeployment{ID: "d-" + req.VersionID}}
+		case appPath + "/deployment/d-v-2":
+			rsp = GetFlinkApplicationDeploymentResponse{FlinkApplicationDeployment: FlinkApplicationDeployment{ID: "d-v-2", Status: newStatus}}
+		case appPath + "/deployment/d-v-1":
+			rsp = GetFlinkApplicationDeploymentResponse{FlinkApplicationDeployment: FlinkApplicationDeployment{ID: "d-v-1", Status: FlinkApplicationDeploymentStatusRunning}}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		if err := json.NewEncoder(w).Encode(rsp); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	return c, &created, func(t *testing.T) {
+		t.Log("teardown Flink Redeploy test case")
+		ts.Close()
+	}
+}
+
+func TestFlinkApplicationDeploymentHandler_Redeploy(t *testing.T) {
+	running := FlinkApplicationDeployment{ID: "d-1", VersionID: "v-1", Parallelism: 3, Status: FlinkApplicationDeploymentStatusRunning}
+	stopped := FlinkApplicationDeployment{
+		ID: "d-1", VersionID: "v-1", Parallelism: 3, Status: FlinkApplicationDeploymentStatusCanceled, LastSavepoint: "s3://savepoints/sp-1",
+	}
+
+	tests := []struct {
+		name         string
+		current      FlinkApplicationDeployment
+		newStatus    string
+		wantErr      error
+		wantRollback bool
+	}{
+		{
+			name:      "running",
+			current:   running,
+			newStatus: FlinkApplicationDeploymentStatusRunning,
+		},
+		{
+			name:         "failed",
+			current:      running,
+			newStatus:    FlinkApplicationDeploymentStatusFailed,
+			wantErr:      ErrFlinkApplicationDeploymentFailed,
+			wantRollback: true,
+		},
+		{
+			name:      "stopped",
+			current:   stopped,
+			newStatus: FlinkApplicationDeploymentStatusRunning,
+		},
+		{
+			// The previous deployment was not running, there is nothing to roll back to
+			name:      "stopped and failed",
+			current:   stopped,
+			newStatus: FlinkApplicationDeploymentStatusFailed,
+			wantErr:   ErrFlinkApplicationDeploymentFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, created, tearDown := setupFlinkRedeployTestCase(t, tt.current, tt.newStatus)
+			defer tearDown(t)
+
+			result, err := c.FlinkApplicationDeployments.Redeploy(context.Background(), "test-pr", "test-sr", "app-1", FlinkApplicationRedeployRequest{
+				Version:      GenericFlinkApplicationVersionRequest{Statement: "INSERT INTO sink SELECT * FROM source"},
+				PollInterval: time.Millisecond,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Redeploy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			assert.Equal(t, "s3://savepoints/sp-1", result.Savepoint)
+			want := []CreateFlinkApplicationDeploymentRequest{
+				{VersionID: "v-2", Parallelism: 3, StartingSavepoint: "s3://savepoints/sp-1"},
+			}
+			if tt.wantRollback {
+				want = append(want, CreateFlinkApplicationDeploymentRequest{VersionID: "v-1", Parallelism: 3, StartingSavepoint: "s3://savepoints/sp-1"})
+				require.NotNil(t, result.Rollback)
+			} else {
+				assert.Nil(t, result.Rollback)
+			}
+			assert.Equal(t, want, *created)
+		})
+	}
+}
+
+func TestFlinkApplicationDeploymentHandler_Redeploy_notRedeployable(t *testing.T) {
+	tests := []struct {
+		name    string
+		current FlinkApplicationDeployment
+	}{
+		{
+			name:    "restarting",
+			current: FlinkApplicationDeployment{ID: "d-1", Status: FlinkApplicationDeploymentStatusRestarting},
+		},
+		{
+			name:    "stopped without a savepoint",
+			current: FlinkApplicationDeployment{ID: "d-1", Status: FlinkApplicationDeploymentStatusFinished},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, created, tearDown := setupFlinkRedeployTestCase(t, tt.current, FlinkApplicationDeploymentStatusRunning)
+			defer tearDown(t)
+
+			_, err := c.FlinkApplicationDeployments.Redeploy(context.Background(), "test-pr", "test-sr", "app-1", FlinkApplicationRedeployRequest{
+				Version:      GenericFlinkApplicationVersionRequest{Statement: "INSERT INTO sink SELECT * FROM source"},
+				PollInterval: time.Millisecond,
+			})
+			assert.ErrorIs(t, err, ErrFlinkApplicationNotRedeployable)
+			assert.Empty(t, *created)
+		})
+	}
+}
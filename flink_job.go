@@ -101,7 +101,7 @@ type (
 			Scheduled    int `json:"SCHEDULED"`
 		} `json:"timestamps"`
 
-		Vertices []interface{} `json:"vertices"`
+		Vertices []FlinkJobVertex `json:"vertices"`
 	}
 
 	// ValidateFlinkJobRequest Aiven API request
@@ -0,0 +1,305 @@
+package aiven
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	FlinkJobStateInitializing = "INITIALIZING"
+	FlinkJobStateCreated      = "CREATED"
+	FlinkJobStateRunning      = "RUNNING"
+	FlinkJobStateFailing      = "FAILING"
+	FlinkJobStateFailed       = "FAILED"
+	FlinkJobStateCancelling   = "CANCELLING"
+	FlinkJobStateCanceled     = "CANCELED"
+	FlinkJobStateFinished     = "FINISHED"
+	FlinkJobStateRestarting   = "RESTARTING"
+	FlinkJobStateSuspended    = "SUSPENDED"
+	FlinkJobStateReconciling  = "RECONCILING"
+
+	FlinkBackpressureLevelOK   = "ok"
+	FlinkBackpressureLevelLow  = "low"
+	FlinkBackpressureLevelHigh = "high"
+)
+
+type (
+	// FlinkJobVertexMetrics holds the I/O and time metrics of a job vertex, times are in milliseconds
+	FlinkJobVertexMetrics struct {
+		ReadBytes                    int64   `json:"read-bytes"`
+		ReadBytesComplete            bool    `json:"read-bytes-complete"`
+		WriteBytes                   int64   `json:"write-bytes"`
+		WriteBytesComplete           bool    `json:"write-bytes-complete"`
+		ReadRecords                  int64   `json:"read-records"`
+		ReadRecordsComplete          bool    `json:"read-records-complete"`
+		WriteRecords                 int64   `json:"write-records"`
+		WriteRecordsComplete         bool    `json:"write-records-complete"`
+		AccumulatedBackpressuredTime int64   `json:"accumulated-backpressured-time"`
+		AccumulatedIdleTime          int64   `json:"accumulated-idle-time"`
+		AccumulatedBusyTime          float64 `json:"accumulated-busy-time"`
+	}
+
+	// FlinkJobVertex is a job vertex, timestamps are in milliseconds since the epoch
+	FlinkJobVertex struct {
+		ID             string                `json:"id"`
+		Name           string                `json:"name"`
+		MaxParallelism int                   `json:"maxParallelism"`
+		Parallelism    int                   `json:"parallelism"`
+		Status         string                `json:"status"`
+		StartTime      int64                 `json:"start-time"`
+		EndTime        int64                 `json:"end-time"`
+		Duration       int64                 `json:"duration"`
+		Tasks          map[string]int        `json:"tasks"`
+		Metrics        FlinkJobVertexMetrics `json:"metrics"`
+	}
+
+	// FlinkJobSubtaskBackpressure is the backpressure of a vertex subtask
+	FlinkJobSubtaskBackpressure struct {
+		Subtask           int     `json:"subtask"`
+		BackpressureLevel string  `json:"backpressure-level"`
+		Ratio             float64 `json:"ratio"`
+		IdleRatio         float64 `json:"idleRatio"`
+		BusyRatio         float64 `json:"busyRatio"`
+	}
+
+	// FlinkJobVertexBackpressureResponse Aiven API response
+	// GET https://api.aiven.io/v1/project/<project>/service/<service_name>/flink/proxy/v1/jobs/<job_id>/vertices/<vertex_id>/backpressure
+	FlinkJobVertexBackpressureResponse struct {
+		APIResponse
+
+		Status            string                        `json:"status"`
+		BackpressureLevel string                        `json:"backpressure-level"`
+		EndTimestamp      int64                         `json:"end-timestamp"`
+		Subtasks          []FlinkJobSubtaskBackpressure `json:"subtasks"`
+	}
+
+	// FlinkJobCheckpointCounts counts the checkpoints of a job
+	FlinkJobCheckpointCounts struct {
+		Restored   int `json:"restored"`
+		Total      int `json:"total"`
+		InProgress int `json:"in_progress"`
+		Completed  int `json:"completed"`
+		Failed     int `json:"failed"`
+	}
+
+	// FlinkJobCheckpointMinMaxAvg summarizes a checkpoint statistic
+	FlinkJobCheckpointMinMaxAvg struct {
+		Min int64 `json:"min"`
+		Max int64 `json:"max"`
+		Avg int64 `json:"avg"`
+	}
+
+	// FlinkJobCheckpointSummary summarizes the completed checkpoints of a job
+	FlinkJobCheckpointSummary struct {
+		StateSize         FlinkJobCheckpointMinMaxAvg `json:"state_size"`
+		CheckpointedSize  FlinkJobCheckpointMinMaxAvg `json:"checkpointed_size"`
+		EndToEndDuration  FlinkJobCheckpointMinMaxAvg `json:"end_to_end_duration"`
+		AlignmentBuffered FlinkJobCheckpointMinMaxAvg `json:"alignment_buffered"`
+	}
+
+	// FlinkJobCheckpoint is a checkpoint or a savepoint of a job
+	FlinkJobCheckpoint struct {
+		ID                 int64  `json:"id"`
+		Status             string `json:"status"`
+		IsSavepoint        bool   `json:"is_savepoint"`
+		TriggerTimestamp   int64  `json:"trigger_timestamp"`
+		LatestAckTimestamp int64  `json:"latest_ack_timestamp"`
+		StateSize          int64  `json:"state_size"`
+		EndToEndDuration   int64  `json:"end_to_end_duration"`
+		ExternalPath       string `json:"external_path"`
+		Discarded          bool   `json:"discarded"`
+		FailureTimestamp   int64  `json:"failure_timestamp"`
+		FailureMessage     string `json:"failure_message"`
+		RestoreTimestamp   int64  `json:"restore_timestamp"`
+	}
+
+	// FlinkJobCheckpointsResponse Aiven API response
+	// GET https://api.aiven.io/v1/project/<project>/service/<service_name>/flink/proxy/v1/jobs/<job_id>/checkpoints
+	FlinkJobCheckpointsResponse struct {
+		APIResponse
+
+		Counts  FlinkJobCheckpointCounts  `json:"counts"`
+		Summary FlinkJobCheckpointSummary `json:"summary"`
+		Latest  struct {
+			Completed *FlinkJobCheckpoint `json:"completed"`
+			Savepoint *FlinkJobCheckpoint `json:"savepoint"`
+			Failed    *FlinkJobCheckpoint `json:"failed"`
+			Restored  *FlinkJobCheckpoint `json:"restored"`
+		} `json:"latest"`
+		History []FlinkJobCheckpoint `json:"history"`
+	}
+
+	// FlinkJobException is an entry of the exception history of a job
+	FlinkJobException struct {
+		ExceptionName string `json:"exceptionName"`
+		Stacktrace    string `json:"stacktrace"`
+		Timestamp     int64  `json:"timestamp"`
+		TaskName      string `json:"taskName"`
+		Location      string `json:"location"`
+	}
+
+	// FlinkJobExceptionsResponse Aiven API response
+	// GET https://api.aiven.io/v1/project/<project>/service/<service_name>/flink/proxy/v1/jobs/<job_id>/exceptions
+	FlinkJobExceptionsResponse struct {
+		APIResponse
+
+		RootException    string `json:"root-exception"`
+		Timestamp        int64  `json:"timestamp"`
+		Truncated        bool   `json:"truncated"`
+		ExceptionHistory struct {
+			Entries   []FlinkJobException `json:"entries"`
+			Truncated bool                `json:"truncated"`
+		} `json:"exceptionHistory"`
+	}
+
+	// FlinkJobHealthPolicy decides when a job is unhealthy.
+	FlinkJobHealthPolicy struct {
+		// MaxFailures is the number of failures tolerated within FailureWindow, disabled when zero.
+		// Failures are counted from the exception history of the job, which Flink truncates,
+		// so a job restarting more often than the history holds is only flagged from what is kept.
+		MaxFailures int
+		// FailureWindow only counts the failures this recent, the whole exception history when zero.
+		FailureWindow time.Duration
+		// StuckAfter is the time a job may stay INITIALIZING or RECONCILING, disabled when zero.
+		StuckAfter time.Duration
+	}
+
+	// FlinkJobHealth is the result of a job health evaluation.
+	FlinkJobHealth struct {
+		JobID   string
+		State   string
+		Healthy bool
+		// Failures is the number of exception history entries within the FailureWindow. It is a lower
+		// bound of the number of restarts when FailuresTruncated is set.
+		Failures int
+		// FailuresTruncated is set when the exception history was truncated by Flink.
+		FailuresTruncated bool
+		Issues            []string
+	}
+)
+
+// flinkMillis converts milliseconds since the epoch as reported by Flink to a time.
+func flinkMillis(ms int64) time.Time {
+	return time.UnixMilli(ms).UTC()
+}
+
+// Vertices returns the vertices of a flink job, they are also part of the job returned by Get
+func (h *FlinkJobHandler) Vertices(ctx context.Context, project, service, jobID string) ([]FlinkJobVertex, error) {
+	r, err := h.Get(ctx, project, service, GetFlinkJobRequest{JobId: jobID})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Vertices, nil
+}
+
+// VertexBackpressure gets the backpressure of a flink job vertex
+func (h *FlinkJobHandler) VertexBackpressure(ctx context.Context, project, service, jobID, vertexID string) (*FlinkJobVertexBackpressureResponse, error) {
+	path := buildPath("project", project, "service", service, "flink", "proxy", "v1", "jobs", jobID, "vertices", vertexID, "backpressure")
+	bts, err := h.client.doGetRequest(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var r FlinkJobVertexBackpressureResponse
+	return &r, checkAPIResponse(bts, &r)
+}
+
+// Checkpoints gets the checkpoint statistics of a flink job
+func (h *FlinkJobHandler) Checkpoints(ctx context.Context, project, service, jobID string) (*FlinkJobCheckpointsResponse, error) {
+	path := buildPath("project", project, "service", service, "flink", "proxy", "v1", "jobs", jobID, "checkpoints")
+	bts, err := h.client.doGetRequest(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var r FlinkJobCheckpointsResponse
+	return &r, checkAPIResponse(bts, &r)
+}
+
+// Exceptions gets the exception history of a flink job
+func (h *FlinkJobHandler) Exceptions(ctx context.Context, project, service, jobID string) (*FlinkJobExceptionsResponse, error) {
+	path := buildPath("project", project, "service", service, "flink", "proxy", "v1", "jobs", jobID, "exceptions")
+	bts, err := h.client.doGetRequest(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var r FlinkJobExceptionsResponse
+	return &r, checkAPIResponse(bts, &r)
+}
+
+// stateSince returns when the job entered its current state, zero when unknown.
+func (r *GetFlinkJobResponse) stateSince() time.Time {
+	var ms int
+	switch r.State {
+	case FlinkJobStateInitializing:
+		ms = r.Timestamps.Initializing
+	case FlinkJobStateReconciling:
+		ms = r.Timestamps.Reconciling
+	case FlinkJobStateCreated:
+		ms = r.Timestamps.Created
+	case FlinkJobStateRunning:
+		ms = r.Timestamps.Running
+	case FlinkJobStateFailed:
+		ms = r.Timestamps.Failed
+	}
+
+	if ms == 0 {
+		return time.Time{}
+	}
+	return flinkMillis(int64(ms))
+}
+
+// EvaluateFlinkJobHealth flags a job failing, failing more than MaxFailures times within FailureWindow
+// according to its exception history, or stuck INITIALIZING or RECONCILING for longer than StuckAfter.
+// The exception history is truncated by Flink, the failures counted from it are a lower bound.
+func EvaluateFlinkJobHealth(job *GetFlinkJobResponse, exceptions *FlinkJobExceptionsResponse, policy FlinkJobHealthPolicy, now time.Time) *FlinkJobHealth {
+	health := &FlinkJobHealth{JobID: job.JID, State: job.State}
+
+	switch job.State {
+	case FlinkJobStateFailed, FlinkJobStateFailing:
+		health.Issues = append(health.Issues, fmt.Sprintf("job is %s", job.State))
+	case FlinkJobStateInitializing, FlinkJobStateReconciling:
+		since := job.stateSince()
+		if policy.StuckAfter > 0 && !since.IsZero() && now.Sub(since) > policy.StuckAfter {
+			health.Issues = append(health.Issues, fmt.Sprintf("job is %s for %s", job.State, now.Sub(since).Round(time.Second)))
+		}
+	}
+
+	if exceptions != nil {
+		health.FailuresTruncated = exceptions.ExceptionHistory.Truncated
+		for _, e := range exceptions.ExceptionHistory.Entries {
+			if policy.FailureWindow <= 0 || now.Sub(flinkMillis(e.Timestamp)) <= policy.FailureWindow {
+				health.Failures++
+			}
+		}
+	}
+
+	if policy.MaxFailures > 0 && health.Failures > policy.MaxFailures {
+		if policy.FailureWindow > 0 {
+			health.Issues = append(health.Issues, fmt.Sprintf("job failed %d times within %s", health.Failures, policy.FailureWindow))
+		} else {
+			health.Issues = append(health.Issues, fmt.Sprintf("job failed %d times", health.Failures))
+		}
+	}
+
+	health.Healthy = len(health.Issues) == 0
+	return health
+}
+
+// Health evaluates the health of a flink job
+func (h *FlinkJobHandler) Health(ctx context.Context, project, service, jobID string, policy FlinkJobHealthPolicy) (*FlinkJobHealth, error) {
+	job, err := h.Get(ctx, project, service, GetFlinkJobRequest{JobId: jobID})
+	if err != nil {
+		return nil, err
+	}
+
+	exceptions, err := h.Exceptions(ctx, project, service, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return EvaluateFlinkJobHealth(job, exceptions, policy, time.Now()), nil
+}
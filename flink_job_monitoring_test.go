@@ -0,0 +1,201 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFlinkJobMonitoringTestCase(t *testing.T) (*Client, func(t *testing.T)) {
+	t.Log("setup Flink Job Monitoring test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+		jobPath      = "/project/test-pr/service/test-sr/flink/proxy/v1/jobs/job-1"
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch r.URL.Path {
+		case "/userauth":
+			_ = json.NewEncoder(w).Encode(authResponse{Token: AccessToken, State: "active"})
+		case jobPath:
+			_, _ = w.Write([]byte(`{
+				"jid": "job-1",
+				"state": "INITIALIZING",
+				"timestamps": {"INITIALIZING": 1700000000000},
+				"vertices": [{
+					"id": "v-1",
+					"name": "Source: orders",
+					"parallelism": 2,
+					"maxParallelism": 128,
+					"status": "RUNNING",
+					"start-time": 1700000000000,
+					"tasks": {"RUNNING": 2},
+					"metrics": {"read-records": 0, "write-records": 42, "accumulated-backpressured-time": 150}
+				}]
+			}`))
+		case jobPath + "/vertices/v-1/backpressure":
+			_, _ = w.Write([]byte(`{
+				"status": "ok",
+				"backpressure-level": "high",
+				"end-timestamp": 1700000000000,
+				"subtasks": [{"subtask": 0, "backpressure-level": "high", "ratio": 0.9, "idleRatio": 0, "busyRatio": 0.1}]
+			}`))
+		case jobPath + "/checkpoints":
+			_, _ = w.Write([]byte(`{
+				"counts": {"total": 3, "completed": 2, "failed": 1},
+				"latest": {"completed": {"id": 2, "status": "COMPLETED", "external_path": "s3://cp/2"}},
+				"history": [{"id": 3, "status": "FAILED"}, {"id": 2, "status": "COMPLETED"}]
+			}`))
+		case jobPath + "/exceptions":
+			_, _ = w.Write([]byte(`{
+				"exceptionHistory": {"entries": [
+					{"exceptionName": "java.io.IOException", "timestamp": 1700000000000, "taskName": "Source: orders"},
+					{"exceptionName": "java.io.IOException", "timestamp": 1700000060000, "taskName": "Source: orders"}
+				], "truncated": true}
+			}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	return c, func(t *testing.T) {
+		t.Log("teardown Flink Job Monitoring test case")
+		ts.Close()
+	}
+}
+
+func TestFlinkJobHandler_Monitoring(t *testing.T) {
+	c, tearDown := setupFlinkJobMonitoringTestCase(t)
+	defer tearDown(t)
+
+	ctx := context.Background()
+
+	job, err := c.FlinkJobs.Get(ctx, "test-pr", "test-sr", GetFlinkJobRequest{JobId: "job-1"})
+	require.NoError(t, err)
+	require.Len(t, job.Vertices, 1)
+	assert.Equal(t, "Source: orders", job.Vertices[0].Name)
+
+	vertices, err := c.FlinkJobs.Vertices(ctx, "test-pr", "test-sr", "job-1")
+	require.NoError(t, err)
+	require.Len(t, vertices, 1)
+	assert.Equal(t, 2, vertices[0].Parallelism)
+	assert.Equal(t, int64(42), vertices[0].Metrics.WriteRecords)
+	assert.Equal(t, int64(150), vertices[0].Metrics.AccumulatedBackpressuredTime)
+
+	bp, err := c.FlinkJobs.VertexBackpressure(ctx, "test-pr", "test-sr", "job-1", vertices[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, FlinkBackpressureLevelHigh, bp.BackpressureLevel)
+	assert.Equal(t, 0.9, bp.Subtasks[0].Ratio)
+
+	cp, err := c.FlinkJobs.Checkpoints(ctx, "test-pr", "test-sr", "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, cp.Counts.Failed)
+	assert.Equal(t, "s3://cp/2", cp.Latest.Completed.ExternalPath)
+	assert.Len(t, cp.History, 2)
+
+	health, err := c.FlinkJobs.Health(ctx, "test-pr", "test-sr", "job-1", FlinkJobHealthPolicy{MaxFailures: 1, StuckAfter: time.Minute})
+	require.NoError(t, err)
+	assert.False(t, health.Healthy)
+	assert.Equal(t, 2, health.Failures)
+	assert.True(t, health.FailuresTruncated)
+	assert.Len(t, health.Issues, 2)
+}
+
+func TestEvaluateFlinkJobHealth(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	exceptions := &FlinkJobExceptionsResponse{}
+	for _, m := range []int{0, 50, 55, 58} {
+		exceptions.ExceptionHistory.Entries = append(exceptions.ExceptionHistory.Entries, FlinkJobException{
+			Timestamp: start.Add(time.Duration(m) * time.Minute).UnixMilli(),
+		})
+	}
+	now := start.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		state    string
+		since    time.Time
+		policy   FlinkJobHealthPolicy
+		failures int
+		issues   []string
+	}{
+		{
+			name:     "running within budget",
+			state:    FlinkJobStateRunning,
+			since:    start,
+			policy:   FlinkJobHealthPolicy{MaxFailures: 3, FailureWindow: 30 * time.Minute},
+			failures: 3,
+		},
+		{
+			name:     "failing repeatedly",
+			state:    FlinkJobStateRunning,
+			since:    start,
+			policy:   FlinkJobHealthPolicy{MaxFailures: 2, FailureWindow: 30 * time.Minute},
+			failures: 3,
+			issues:   []string{"job failed 3 times within 30m0s"},
+		},
+		{
+			name:     "failing repeatedly without window",
+			state:    FlinkJobStateRunning,
+			since:    start,
+			policy:   FlinkJobHealthPolicy{MaxFailures: 2},
+			failures: 4,
+			issues:   []string{"job failed 4 times"},
+		},
+		{
+			name:     "stuck reconciling",
+			state:    FlinkJobStateReconciling,
+			since:    now.Add(-10 * time.Minute),
+			policy:   FlinkJobHealthPolicy{StuckAfter: 5 * time.Minute},
+			failures: 4,
+			issues:   []string{"job is RECONCILING for 10m0s"},
+		},
+		{
+			name:     "initializing within threshold",
+			state:    FlinkJobStateInitializing,
+			since:    now.Add(-time.Minute),
+			policy:   FlinkJobHealthPolicy{StuckAfter: 5 * time.Minute},
+			failures: 4,
+		},
+		{
+			name:     "failed",
+			state:    FlinkJobStateFailed,
+			since:    now,
+			failures: 4,
+			issues:   []string{"job is FAILED"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &GetFlinkJobResponse{JID: "job-1", State: tt.state}
+			ms := int(tt.since.UnixMilli())
+			job.Timestamps.Running = ms
+			job.Timestamps.Reconciling = ms
+			job.Timestamps.Initializing = ms
+			job.Timestamps.Failed = ms
+
+			got := EvaluateFlinkJobHealth(job, exceptions, tt.policy, now)
+			assert.Equal(t, tt.failures, got.Failures)
+			assert.Equal(t, tt.issues, got.Issues)
+			assert.Equal(t, len(tt.issues) == 0, got.Healthy)
+		})
+	}
+}
@@ -0,0 +1,447 @@
+package aiven
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	flinkSQLTokenIdent = iota
+	flinkSQLTokenQuotedIdent
+	flinkSQLTokenString
+	flinkSQLTokenNumber
+	flinkSQLTokenSymbol
+)
+
+// flinkSQLKeywords are the keywords which are never table names or aliases.
+var flinkSQLKeywords = map[string]bool{
+	"ALL": true, "AND": true, "AS": true, "BY": true, "CREATE": true, "CROSS": true, "EXCEPT": true,
+	"EXISTS": true, "FOR": true, "FROM": true, "FULL": true, "GROUP": true, "HAVING": true, "IF": true,
+	"IN": true, "INNER": true, "INSERT": true, "INTERSECT": true, "INTO": true, "JOIN": true,
+	"LATERAL": true, "LEFT": true, "LIMIT": true, "MATCH_RECOGNIZE": true, "NATURAL": true, "NOT": true,
+	"ON": true, "OR": true, "ORDER": true, "OUTER": true, "OVERWRITE": true, "RIGHT": true, "SELECT": true,
+	"TABLE": true, "TEMPORARY": true, "UNION": true, "UNNEST": true, "USING": true, "VALUES": true,
+	"VIEW": true, "WHERE": true, "WINDOW": true, "WITH": true,
+}
+
+type (
+	// FlinkSQLDiagnostic is a problem found by the local analysis of a Flink application version.
+	FlinkSQLDiagnostic struct {
+		// Relation is "statement", "sources[i]" or "sinks[i]".
+		Relation string
+		ValidateFlinkApplicationVersionStatementError
+	}
+
+	flinkSQLToken struct {
+		kind int
+		// text is the token as written, without quotes for quoted identifiers.
+		text string
+		pos  flinkPosition
+	}
+
+	// flinkSQLName is a table name found in a statement.
+	flinkSQLName struct {
+		name  string
+		parts int
+		pos   flinkPosition
+	}
+
+	// flinkSQLAnalysis holds the tables a statement defines and references.
+	flinkSQLAnalysis struct {
+		defined []flinkSQLName
+		ctes    []flinkSQLName
+		refs    []flinkSQLName
+		errors  []ValidateFlinkApplicationVersionStatementError
+	}
+)
+
+func flinkSQLError(pos flinkPosition, format string, a ...interface{}) ValidateFlinkApplicationVersionStatementError {
+	return ValidateFlinkApplicationVersionStatementError{Message: fmt.Sprintf(format, a...), Position: pos}
+}
+
+// tokenizeFlinkSQL splits the SQL into tokens, skipping whitespace and comments.
+// Positions are 1-based, the end of a token is its last character.
+func tokenizeFlinkSQL(sql string) ([]flinkSQLToken, *ValidateFlinkApplicationVersionStatementError) {
+	runes := []rune(sql)
+	line, col := 1, 1
+	lastLine, lastCol := 1, 1
+	i := 0
+
+	advance := func() {
+		lastLine, lastCol = line, col
+		if runes[i] == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+		i++
+	}
+	peek := func(k int) rune {
+		if i+k < len(runes) {
+			return runes[i+k]
+		}
+		return 0
+	}
+
+	var tokens []flinkSQLToken
+	for i < len(runes) {
+		r := runes[i]
+		start := flinkPosition{LineNumber: line, CharacterNumber: col}
+
+		switch {
+		case unicode.IsSpace(r):
+			advance()
+			continue
+		case r == '-' && peek(1) == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				advance()
+			}
+			continue
+		case r == '/' && peek(1) == '*':
+			advance()
+			advance()
+			for i < len(runes) && !(runes[i] == '*' && peek(1) == '/') {
+				advance()
+			}
+			if i >= len(runes) {
+				err := flinkSQLError(start, "unterminated comment")
+				return nil, &err
+			}
+			advance()
+			advance()
+			continue
+		}
+
+		var tok flinkSQLToken
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			tok.kind = flinkSQLTokenString
+			if r == '`' {
+				tok.kind = flinkSQLTokenQuotedIdent
+			}
+
+			var b strings.Builder
+			advance()
+			closed := false
+			for i < len(runes) {
+				if runes[i] == r {
+					// A doubled quote is an escaped quote
+					if peek(1) == r {
+						b.WriteRune(r)
+						advance()
+						advance()
+						continue
+					}
+					advance()
+					closed = true
+					break
+				}
+				b.WriteRune(runes[i])
+				advance()
+			}
+			if !closed {
+				what := "string"
+				if tok.kind == flinkSQLTokenQuotedIdent {
+					what = "identifier"
+				}
+				err := flinkSQLError(start, "unterminated quoted %s", what)
+				return nil, &err
+			}
+			tok.text = b.String()
+		case unicode.IsLetter(r) || r == '_':
+			tok.kind = flinkSQLTokenIdent
+			from := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				advance()
+			}
+			tok.text = string(runes[from:i])
+		case unicode.IsDigit(r):
+			tok.kind = flinkSQLTokenNumber
+			from := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == '_') {
+				advance()
+			}
+			tok.text = string(runes[from:i])
+		default:
+			tok.kind = flinkSQLTokenSymbol
+			tok.text = string(r)
+			advance()
+		}
+
+		tok.pos = start
+		tok.pos.EndLineNumber, tok.pos.EndCharacterNumber = lastLine, lastCol
+		tokens = append(tokens, tok)
+	}
+
+	return tokens, nil
+}
+
+// analyzeFlinkSQL finds the tables, views and common table expressions the tokens define
+// and the tables they read from or insert into.
+func analyzeFlinkSQL(tokens []flinkSQLToken) *flinkSQLAnalysis {
+	a := new(flinkSQLAnalysis)
+
+	isSymbol := func(i int, s string) bool {
+		return i >= 0 && i < len(tokens) && tokens[i].kind == flinkSQLTokenSymbol && tokens[i].text == s
+	}
+	isKeyword := func(i int, kws ...string) bool {
+		if i < 0 || i >= len(tokens) || tokens[i].kind != flinkSQLTokenIdent {
+			return false
+		}
+		for _, kw := range kws {
+			if strings.EqualFold(tokens[i].text, kw) {
+				return true
+			}
+		}
+		return false
+	}
+	isName := func(i int) bool {
+		if i < 0 || i >= len(tokens) {
+			return false
+		}
+		t := tokens[i]
+		return t.kind == flinkSQLTokenQuotedIdent || t.kind == flinkSQLTokenIdent && !flinkSQLKeywords[strings.ToUpper(t.text)]
+	}
+	// readName reads a possibly qualified name, returns the index right after it.
+	readName := func(i int) (flinkSQLName, int, bool) {
+		if !isName(i) {
+			return flinkSQLName{}, i, false
+		}
+
+		n := flinkSQLName{name: tokens[i].text, parts: 1, pos: tokens[i].pos}
+		i++
+		for isSymbol(i, ".") && isName(i+1) {
+			n.name += "." + tokens[i+1].text
+			n.parts++
+			n.pos.EndLineNumber, n.pos.EndCharacterNumber = tokens[i+1].pos.EndLineNumber, tokens[i+1].pos.EndCharacterNumber
+			i += 2
+		}
+		return n, i, true
+	}
+	// matching returns the index of the parenthesis closing the one at i.
+	matching := func(i int) int {
+		depth := 0
+		for ; i < len(tokens); i++ {
+			switch {
+			case isSymbol(i, "("):
+				depth++
+			case isSymbol(i, ")"):
+				depth--
+				if depth == 0 {
+					return i
+				}
+			}
+		}
+		return len(tokens)
+	}
+
+	// parens tracks the open parentheses, query is set for subqueries
+	type paren struct {
+		pos   flinkPosition
+		query bool
+	}
+	var parens []paren
+
+	for i := 0; i < len(tokens); i++ {
+		inQuery := len(parens) == 0 || parens[len(parens)-1].query
+
+		switch {
+		case isSymbol(i, "("):
+			parens = append(parens, paren{pos: tokens[i].pos, query: isKeyword(i+1, "SELECT", "WITH", "VALUES")})
+		case isSymbol(i, ")"):
+			if len(parens) == 0 {
+				a.errors = append(a.errors, flinkSQLError(tokens[i].pos, "unexpected closing parenthesis"))
+				continue
+			}
+			parens = parens[:len(parens)-1]
+		case isKeyword(i, "CREATE"):
+			j := i + 1
+			if isKeyword(j, "TEMPORARY") {
+				j++
+			}
+			if !isKeyword(j, "TABLE", "VIEW") {
+				continue
+			}
+			j++
+			if isKeyword(j, "IF") && isKeyword(j+1, "NOT") && isKeyword(j+2, "EXISTS") {
+				j += 3
+			}
+			if n, next, ok := readName(j); ok {
+				a.defined = append(a.defined, n)
+				i = next - 1
+			}
+		case inQuery && isKeyword(i, "FROM", "JOIN"):
+			for j := i + 1; ; {
+				n, next, ok := readName(j)
+				if !ok {
+					break
+				}
+				a.refs = append(a.refs, n)
+
+				// Skip the alias, a comma continues the list of tables
+				j = next
+				if isKeyword(j, "AS") {
+					j++
+				}
+				if isName(j) {
+					j++
+				}
+				if !isSymbol(j, ",") {
+					break
+				}
+				j++
+			}
+		case isKeyword(i, "INTO", "OVERWRITE") && isKeyword(i-1, "INSERT"):
+			if n, _, ok := readName(i + 1); ok {
+				a.refs = append(a.refs, n)
+			}
+		case isKeyword(i, "TABLE") && !isKeyword(i-1, "CREATE", "TEMPORARY"):
+			// Table arguments of table valued functions, e.g. TUMBLE(TABLE orders, ...)
+			if n, _, ok := readName(i + 1); ok {
+				a.refs = append(a.refs, n)
+			}
+		case isKeyword(i, "WITH") && !isSymbol(i+1, "("):
+			for j := i + 1; ; {
+				n, next, ok := readName(j)
+				if !ok {
+					break
+				}
+				if isSymbol(next, "(") {
+					// Column list of the common table expression
+					next = matching(next) + 1
+				}
+				if !isKeyword(next, "AS") || !isSymbol(next+1, "(") {
+					break
+				}
+				a.ctes = append(a.ctes, n)
+
+				j = matching(next+1) + 1
+				if !isSymbol(j, ",") {
+					break
+				}
+				j++
+			}
+		}
+	}
+
+	for _, p := range parens {
+		a.errors = append(a.errors, flinkSQLError(p.pos, "unclosed parenthesis"))
+	}
+
+	return a
+}
+
+// AnalyzeFlinkApplicationVersion checks a Flink application version locally before the Validate round trip:
+// the statement and the CREATE TABLE of every relation must parse, every relation must define exactly one table
+// and use one of the given integration IDs, and every table the statement reads from or inserts into must be
+// defined by a relation or by the statement itself. The integration check is skipped when integrationIDs is nil.
+// Qualified table names, e.g. catalog.db.table, are not checked.
+func AnalyzeFlinkApplicationVersion(req GenericFlinkApplicationVersionRequest, integrationIDs []string) []FlinkSQLDiagnostic {
+	var diags []FlinkSQLDiagnostic
+	report := func(relation string, err ValidateFlinkApplicationVersionStatementError) {
+		diags = append(diags, FlinkSQLDiagnostic{Relation: relation, ValidateFlinkApplicationVersionStatementError: err})
+	}
+
+	integrations := make(map[string]bool, len(integrationIDs))
+	for _, id := range integrationIDs {
+		integrations[id] = true
+	}
+
+	// tables maps the tables defined by the relations to the relation
+	tables := make(map[string]string)
+	checkRelation := func(relation string, r FlinkApplicationVersionRelation) {
+		var pos flinkPosition
+
+		tokens, errT := tokenizeFlinkSQL(r.CreateTable)
+		switch {
+		case errT != nil:
+			report(relation, *errT)
+		case len(tokens) == 0:
+			report(relation, flinkSQLError(pos, "create_table is empty"))
+		default:
+			a := analyzeFlinkSQL(tokens)
+			for _, err := range a.errors {
+				report(relation, err)
+			}
+
+			if len(a.defined) != 1 {
+				report(relation, flinkSQLError(tokens[0].pos, "create_table must define exactly one table, found %d", len(a.defined)))
+				break
+			}
+
+			n := a.defined[0]
+			pos = n.pos
+			if other, ok := tables[n.name]; ok {
+				report(relation, flinkSQLError(pos, "table %s is already defined by %s", n.name, other))
+			} else {
+				tables[n.name] = relation
+			}
+		}
+
+		switch {
+		case r.IntegrationID == "":
+			report(relation, flinkSQLError(pos, "integration_id is empty"))
+		case integrationIDs != nil && !integrations[r.IntegrationID]:
+			report(relation, flinkSQLError(pos, "integration %s does not exist", r.IntegrationID))
+		}
+	}
+
+	for i, r := range req.Sources {
+		checkRelation(fmt.Sprintf("sources[%d]", i), r)
+	}
+	for i, r := range req.Sinks {
+		checkRelation(fmt.Sprintf("sinks[%d]", i), r)
+	}
+
+	const relation = "statement"
+	tokens, errT := tokenizeFlinkSQL(req.Statement)
+	if errT != nil {
+		report(relation, *errT)
+		return diags
+	}
+	if len(tokens) == 0 {
+		report(relation, flinkSQLError(flinkPosition{}, "statement is empty"))
+		return diags
+	}
+
+	a := analyzeFlinkSQL(tokens)
+	for _, err := range a.errors {
+		report(relation, err)
+	}
+
+	known := make(map[string]bool)
+	for _, n := range append(a.defined, a.ctes...) {
+		known[n.name] = true
+	}
+
+	for _, ref := range a.refs {
+		if ref.parts > 1 || known[ref.name] || tables[ref.name] != "" {
+			continue
+		}
+		report(relation, flinkSQLError(ref.pos, "table %s is not defined", ref.name))
+	}
+
+	return diags
+}
+
+// Analyze runs AnalyzeFlinkApplicationVersion with the integrations of the Flink service.
+func (h *FlinkApplicationVersionHandler) Analyze(
+	ctx context.Context,
+	project string,
+	service string,
+	req GenericFlinkApplicationVersionRequest,
+) ([]FlinkSQLDiagnostic, error) {
+	integrations, err := h.client.ServiceIntegrations.List(ctx, project, service)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(integrations))
+	for _, i := range integrations {
+		ids = append(ids, i.ServiceIntegrationID)
+	}
+
+	return AnalyzeFlinkApplicationVersion(req, ids), nil
+}
@@ -0,0 +1,101 @@
+package aiven
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeFlinkApplicationVersion(t *testing.T) {
+	sources := []FlinkApplicationVersionRelation{{
+		CreateTable:   "CREATE TABLE orders (\n  id INT,\n  ts TIMESTAMP(3)\n) WITH ('connector' = 'kafka')",
+		IntegrationID: "si-kafka",
+	}}
+	sinks := []FlinkApplicationVersionRelation{{
+		CreateTable:   "CREATE TABLE `order totals` (id INT, total BIGINT) WITH ('connector' = 'jdbc')",
+		IntegrationID: "si-pg",
+	}}
+
+	tests := []struct {
+		name           string
+		req            GenericFlinkApplicationVersionRequest
+		integrationIDs []string
+		want           []FlinkSQLDiagnostic
+	}{
+		{
+			name: "valid",
+			req: GenericFlinkApplicationVersionRequest{
+				Statement: "-- totals per order\n" +
+					"INSERT INTO `order totals`\n" +
+					"WITH recent AS (SELECT * FROM orders o WHERE EXTRACT(YEAR FROM ts) > 2020)\n" +
+					"SELECT id, COUNT(*) FROM TABLE(TUMBLE(TABLE recent, DESCRIPTOR(ts), INTERVAL '1' MINUTES)) GROUP BY id",
+				Sources: sources,
+				Sinks:   sinks,
+			},
+			integrationIDs: []string{"si-kafka", "si-pg"},
+		},
+		{
+			name: "undefined tables",
+			req: GenericFlinkApplicationVersionRequest{
+				Statement: "INSERT INTO totals\nSELECT o.id FROM orders o, payments p, cat.db.external e\n  JOIN customers c ON o.id = c.id",
+				Sources:   sources,
+			},
+			want: []FlinkSQLDiagnostic{
+				{Relation: "statement", ValidateFlinkApplicationVersionStatementError: ValidateFlinkApplicationVersionStatementError{
+					Message:  "table totals is not defined",
+					Position: flinkPosition{LineNumber: 1, CharacterNumber: 13, EndLineNumber: 1, EndCharacterNumber: 18},
+				}},
+				{Relation: "statement", ValidateFlinkApplicationVersionStatementError: ValidateFlinkApplicationVersionStatementError{
+					Message:  "table payments is not defined",
+					Position: flinkPosition{LineNumber: 2, CharacterNumber: 28, EndLineNumber: 2, EndCharacterNumber: 35},
+				}},
+				{Relation: "statement", ValidateFlinkApplicationVersionStatementError: ValidateFlinkApplicationVersionStatementError{
+					Message:  "table customers is not defined",
+					Position: flinkPosition{LineNumber: 3, CharacterNumber: 8, EndLineNumber: 3, EndCharacterNumber: 16},
+				}},
+			},
+		},
+		{
+			name: "relation errors",
+			req: GenericFlinkApplicationVersionRequest{
+				Statement: "INSERT INTO sink SELECT * FROM (SELECT * FROM orders",
+				Sources: []FlinkApplicationVersionRelation{
+					sources[0],
+					{CreateTable: "CREATE TABLE orders (id INT)", IntegrationID: "si-unknown"},
+				},
+				Sinks: []FlinkApplicationVersionRelation{{CreateTable: "CREATE TABLE sink (name STRING) WITH ('k' = 'v)"}},
+			},
+			integrationIDs: []string{"si-kafka"},
+			want: []FlinkSQLDiagnostic{
+				{Relation: "sources[1]", ValidateFlinkApplicationVersionStatementError: ValidateFlinkApplicationVersionStatementError{
+					Message:  "table orders is already defined by sources[0]",
+					Position: flinkPosition{LineNumber: 1, CharacterNumber: 14, EndLineNumber: 1, EndCharacterNumber: 19},
+				}},
+				{Relation: "sources[1]", ValidateFlinkApplicationVersionStatementError: ValidateFlinkApplicationVersionStatementError{
+					Message:  "integration si-unknown does not exist",
+					Position: flinkPosition{LineNumber: 1, CharacterNumber: 14, EndLineNumber: 1, EndCharacterNumber: 19},
+				}},
+				{Relation: "sinks[0]", ValidateFlinkApplicationVersionStatementError: ValidateFlinkApplicationVersionStatementError{
+					Message:  "unterminated quoted string",
+					Position: flinkPosition{LineNumber: 1, CharacterNumber: 45},
+				}},
+				{Relation: "sinks[0]", ValidateFlinkApplicationVersionStatementError: ValidateFlinkApplicationVersionStatementError{
+					Message: "integration_id is empty",
+				}},
+				{Relation: "statement", ValidateFlinkApplicationVersionStatementError: ValidateFlinkApplicationVersionStatementError{
+					Message:  "unclosed parenthesis",
+					Position: flinkPosition{LineNumber: 1, CharacterNumber: 32, EndLineNumber: 1, EndCharacterNumber: 32},
+				}},
+				{Relation: "statement", ValidateFlinkApplicationVersionStatementError: ValidateFlinkApplicationVersionStatementError{
+					Message:  "table sink is not defined",
+					Position: flinkPosition{LineNumber: 1, CharacterNumber: 13, EndLineNumber: 1, EndCharacterNumber: 16},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AnalyzeFlinkApplicationVersion(tt.req, tt.integrationIDs))
+		})
+	}
+}
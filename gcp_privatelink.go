@@ -182,3 +182,74 @@ func (h *GCPPrivatelinkHandler) ConnectionApprove(
 
 	return checkAPIResponse(rsp, nil)
 }
+
+func newGCPPrivatelinkInfo(rsp *GCPPrivatelinkResponse) *PrivatelinkInfo {
+	return &PrivatelinkInfo{
+		Cloud:     PrivatelinkCloudGoogle,
+		State:     newPrivatelinkState(rsp.State),
+		RawState:  rsp.State,
+		ServiceID: rsp.GoogleServiceAttachment,
+	}
+}
+
+// Cloud returns the cloud of the Privatelink.
+func (h *GCPPrivatelinkHandler) Cloud() string {
+	return PrivatelinkCloudGoogle
+}
+
+// Enable creates the GCP Privatelink, it takes no options.
+func (h *GCPPrivatelinkHandler) Enable(ctx context.Context, project, serviceName string, _ PrivatelinkOptions) (*PrivatelinkInfo, error) {
+	rsp, err := h.Create(ctx, project, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGCPPrivatelinkInfo(rsp), nil
+}
+
+// Info retrieves the GCP Privatelink as a PrivatelinkInfo.
+func (h *GCPPrivatelinkHandler) Info(ctx context.Context, project, serviceName string) (*PrivatelinkInfo, error) {
+	rsp, err := h.Get(ctx, project, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGCPPrivatelinkInfo(rsp), nil
+}
+
+// Disable deletes the GCP Privatelink.
+func (h *GCPPrivatelinkHandler) Disable(ctx context.Context, project, serviceName string) error {
+	return h.Delete(ctx, project, serviceName)
+}
+
+// ListConnections lists the GCP Privatelink connections as PrivatelinkConnections.
+func (h *GCPPrivatelinkHandler) ListConnections(ctx context.Context, project, serviceName string) ([]PrivatelinkConnection, error) {
+	rsp, err := h.ConnectionsList(ctx, project, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	conns := make([]PrivatelinkConnection, 0, len(rsp.Connections))
+	for _, c := range rsp.Connections {
+		conns = append(conns, PrivatelinkConnection{
+			ID:            c.PrivatelinkConnectionID,
+			State:         newPrivatelinkConnectionState(c.State),
+			RawState:      c.State,
+			EndpointID:    c.PSCConnectionID,
+			UserIPAddress: c.UserIPAddress,
+		})
+	}
+
+	return conns, nil
+}
+
+// ApproveConnection approves the connection with the user IP address of its PSC endpoint, which GCP requires.
+func (h *GCPPrivatelinkHandler) ApproveConnection(ctx context.Context, project, serviceName, connectionID, userIPAddress string) error {
+	if userIPAddress == "" {
+		return fmt.Errorf("%w: approve connection %s on %s", ErrPrivatelinkUserIPAddressRequired, connectionID, PrivatelinkCloudGoogle)
+	}
+
+	return h.ConnectionApprove(ctx, project, serviceName, connectionID, GCPPrivatelinkConnectionApproveRequest{
+		UserIPAddress: userIPAddress,
+	})
+}
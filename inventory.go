@@ -0,0 +1,313 @@
+package aiven
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InventoryDepth controls how deep the inventory crawler goes.
+type InventoryDepth int
+
+const (
+	// InventoryDepthProjects crawls organizations, their users, billing groups and projects.
+	InventoryDepthProjects InventoryDepth = iota
+	// InventoryDepthServices crawls services as well.
+	InventoryDepthServices
+	// InventoryDepthDetails crawls service users, integrations and project VPCs as well.
+	InventoryDepthDetails
+)
+
+// defaultInventoryConcurrency is the number of projects crawled concurrently by default.
+const defaultInventoryConcurrency = 4
+
+type (
+	// InventoryOptions configures the inventory crawler.
+	InventoryOptions struct {
+		Depth InventoryDepth
+		// Concurrency is the number of projects crawled concurrently, defaults to 4.
+		Concurrency int
+		// IncludeProject filters the crawled projects, optional.
+		IncludeProject func(project *Project) bool
+		// IncludeService filters the crawled services, optional.
+		IncludeService func(service *Service) bool
+	}
+
+	// Inventory is the typed inventory graph of a set of organizations.
+	Inventory struct {
+		CrawlTime     time.Time                `json:"crawl_time"`
+		Organizations []*InventoryOrganization `json:"organizations"`
+	}
+
+	// InventoryOrganization represents an organization, its users and billing groups.
+	InventoryOrganization struct {
+		ID            string                   `json:"organization_id"`
+		Name          string                   `json:"organization_name"`
+		Tier          OrganizationTier         `json:"tier"`
+		Users         []InventoryUser          `json:"users"`
+		BillingGroups []*InventoryBillingGroup `json:"billing_groups"`
+		// Projects lists the projects whose billing group is not visible.
+		Projects []*InventoryProject `json:"projects,omitempty"`
+	}
+
+	// InventoryUser represents an organization user.
+	InventoryUser struct {
+		UserID string `json:"user_id"`
+		Email  string `json:"email"`
+		State  string `json:"state"`
+	}
+
+	// InventoryBillingGroup represents a billing group and its projects.
+	InventoryBillingGroup struct {
+		ID       string              `json:"billing_group_id"`
+		Name     string              `json:"billing_group_name"`
+		Projects []*InventoryProject `json:"projects"`
+	}
+
+	// InventoryProject represents a project, its services and VPCs.
+	InventoryProject struct {
+		Name         string              `json:"project_name"`
+		DefaultCloud string              `json:"default_cloud"`
+		Tags         map[string]string   `json:"tags,omitempty"`
+		Services     []*InventoryService `json:"services,omitempty"`
+		VPCs         []InventoryVPC      `json:"vpcs,omitempty"`
+	}
+
+	// InventoryService represents a service, its users and integrations.
+	// Service user credentials are not part of the inventory.
+	InventoryService struct {
+		Name         string                 `json:"service_name"`
+		Type         string                 `json:"service_type"`
+		Plan         string                 `json:"plan"`
+		CloudName    string                 `json:"cloud_name"`
+		State        string                 `json:"state"`
+		ProjectVPCID *string                `json:"project_vpc_id,omitempty"`
+		Users        []InventoryServiceUser `json:"users,omitempty"`
+		Integrations []InventoryIntegration `json:"integrations,omitempty"`
+	}
+
+	// InventoryServiceUser represents a service user without its credentials.
+	InventoryServiceUser struct {
+		Username string `json:"username"`
+		Type     string `json:"type"`
+	}
+
+	// InventoryIntegration represents a service integration.
+	InventoryIntegration struct {
+		ID                    string  `json:"service_integration_id"`
+		Type                  string  `json:"integration_type"`
+		SourceService         *string `json:"source_service"`
+		DestinationService    *string `json:"dest_service"`
+		SourceEndpointID      *string `json:"source_endpoint_id,omitempty"`
+		DestinationEndpointID *string `json:"dest_endpoint_id,omitempty"`
+	}
+
+	// InventoryVPC represents a project VPC.
+	InventoryVPC struct {
+		ProjectVPCID string `json:"project_vpc_id"`
+		CloudName    string `json:"cloud_name"`
+		NetworkCIDR  string `json:"network_cidr"`
+		State        string `json:"state"`
+	}
+)
+
+// forEachConcurrently calls fn for every index with at most concurrency calls in flight.
+// The first error cancels the context passed to the remaining calls and is returned.
+func forEachConcurrently(ctx context.Context, n, concurrency int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		sem      = make(chan struct{}, concurrency)
+	)
+
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := fn(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+
+	return firstErr
+}
+
+// Crawl builds the inventory of the given organizations.
+func (c *Client) Crawl(ctx context.Context, opts InventoryOptions, organizationIDs ...string) (*Inventory, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultInventoryConcurrency
+	}
+
+	billingGroups, err := c.BillingGroup.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &Inventory{CrawlTime: time.Now().UTC()}
+	for _, id := range organizationIDs {
+		org, err := c.crawlOrganization(ctx, opts, id, billingGroups)
+		if err != nil {
+			return nil, err
+		}
+		inv.Organizations = append(inv.Organizations, org)
+	}
+
+	return inv, nil
+}
+
+func (c *Client) crawlOrganization(ctx context.Context, opts InventoryOptions, id string, billingGroups []BillingGroup) (*InventoryOrganization, error) {
+	info, err := c.Organization.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	org := &InventoryOrganization{ID: info.ID, Name: info.Name, Tier: info.Tier}
+
+	users, err := c.OrganizationUser.List(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users.Users {
+		org.Users = append(org.Users, InventoryUser{UserID: u.UserID, Email: u.UserInfo.UserEmail, State: u.UserInfo.State})
+	}
+
+	groups := make(map[string]*InventoryBillingGroup)
+	for _, bg := range billingGroups {
+		if PointerToString(bg.AccountId) != info.AccountID {
+			continue
+		}
+
+		g := &InventoryBillingGroup{ID: bg.Id, Name: bg.BillingGroupName}
+		groups[bg.Id] = g
+		org.BillingGroups = append(org.BillingGroups, g)
+	}
+
+	allProjects, err := c.ProjectOrganization.OrganizationProjects(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []*Project
+	for _, p := range allProjects {
+		if opts.IncludeProject == nil || opts.IncludeProject(p) {
+			projects = append(projects, p)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+
+	crawled := make([]*InventoryProject, len(projects))
+	err = forEachConcurrently(ctx, len(projects), opts.Concurrency, func(ctx context.Context, i int) error {
+		p, err := c.crawlProject(ctx, opts, projects[i])
+		crawled[i] = p
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range projects {
+		if g, ok := groups[p.BillingGroupId]; ok {
+			g.Projects = append(g.Projects, crawled[i])
+		} else {
+			org.Projects = append(org.Projects, crawled[i])
+		}
+	}
+
+	return org, nil
+}
+
+func (c *Client) crawlProject(ctx context.Context, opts InventoryOptions, p *Project) (*InventoryProject, error) {
+	project := &InventoryProject{Name: p.Name, DefaultCloud: p.DefaultCloud, Tags: p.Tags}
+	if opts.Depth < InventoryDepthServices {
+		return project, nil
+	}
+
+	services, err := c.Services.List(ctx, p.Name)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+
+	for _, s := range services {
+		if opts.IncludeService != nil && !opts.IncludeService(s) {
+			continue
+		}
+		project.Services = append(project.Services, newInventoryService(opts, s))
+	}
+
+	if opts.Depth < InventoryDepthDetails {
+		return project, nil
+	}
+
+	vpcs, err := c.VPCs.List(ctx, p.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vpcs {
+		project.VPCs = append(project.VPCs, InventoryVPC{
+			ProjectVPCID: v.ProjectVPCID,
+			CloudName:    v.CloudName,
+			NetworkCIDR:  v.NetworkCIDR,
+			State:        v.State,
+		})
+	}
+
+	return project, nil
+}
+
+func newInventoryService(opts InventoryOptions, s *Service) *InventoryService {
+	svc := &InventoryService{
+		Name:      s.Name,
+		Type:      s.Type,
+		Plan:      s.Plan,
+		CloudName: s.CloudName,
+		State:     s.State,
+	}
+
+	if opts.Depth < InventoryDepthDetails {
+		return svc
+	}
+
+	svc.ProjectVPCID = s.ProjectVPCID
+	for _, u := range s.Users {
+		svc.Users = append(svc.Users, InventoryServiceUser{Username: u.Username, Type: u.Type})
+	}
+	for _, i := range s.Integrations {
+		svc.Integrations = append(svc.Integrations, InventoryIntegration{
+			ID:                    i.ServiceIntegrationID,
+			Type:                  i.IntegrationType,
+			SourceService:         i.SourceService,
+			DestinationService:    i.DestinationService,
+			SourceEndpointID:      i.SourceEndpointID,
+			DestinationEndpointID: i.DestinationEndpointID,
+		})
+	}
+
+	return svc
+}
@@ -0,0 +1,150 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupInventoryTestCase(t *testing.T) (*Client, func(t *testing.T)) {
+	t.Log("setup Inventory test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		var rsp interface{}
+		switch r.URL.Path {
+		case "/userauth":
+			rsp = authResponse{Token: AccessToken, State: "active"}
+		case "/billing-group":
+			rsp = BillingGroupListResponse{BillingGroupList: []BillingGroup{
+				{Id: "bg-1", BillingGroupRequest: BillingGroupRequest{BillingGroupName: "main", AccountId: ToStringPointer("a-1")}},
+				{Id: "bg-2", BillingGroupRequest: BillingGroupRequest{BillingGroupName: "other", AccountId: ToStringPointer("a-2")}},
+			}}
+		case "/organization/org-1":
+			rsp = OrganizationInfo{ID: "org-1", Name: "test-org", AccountID: "a-1", Tier: OrganizationTierBusiness}
+		case "/organization/org-1/user":
+			rsp = OrganizationUserList{Users: []OrganizationMemberInfo{
+				{UserID: "u-1", UserInfo: OrganizationUserInfo{UserEmail: "test@aiven.io", State: "active"}},
+			}}
+		case "/organization/org-1/projects":
+			rsp = ProjectListResponse{Projects: []*Project{
+				{Name: "test-pr-b", BillingGroupId: "bg-unknown"},
+				{Name: "test-pr-a", BillingGroupId: "bg-1"},
+				{Name: "skipped-pr", BillingGroupId: "bg-1"},
+			}}
+		case "/project/test-pr-a/service", "/project/test-pr-b/service":
+			rsp = ServiceListResponse{Services: []*Service{
+				{
+					Name:  "test-sr",
+					Type:  "pg",
+					State: ServiceStateRunning,
+					Users: []*ServiceUser{{Username: "avnadmin", Password: "secret", Type: "primary"}},
+					Integrations: []*ServiceIntegration{
+						{ServiceIntegrationID: "si-1", IntegrationType: "metrics", SourceService: ToStringPointer("test-sr")},
+					},
+				},
+				{Name: "test-kafka", Type: "kafka"},
+			}}
+		case "/project/test-pr-a/vpcs", "/project/test-pr-b/vpcs":
+			rsp = VPCListResponse{VPCs: []*VPC{{ProjectVPCID: "vpc-1", CloudName: "aws-eu-west-1", NetworkCIDR: "10.0.0.0/24"}}}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		if err := json.NewEncoder(w).Encode(rsp); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	return c, func(t *testing.T) {
+		t.Log("teardown Inventory test case")
+		ts.Close()
+	}
+}
+
+func TestClient_Crawl(t *testing.T) {
+	c, tearDown := setupInventoryTestCase(t)
+	defer tearDown(t)
+
+	inv, err := c.Crawl(context.Background(), InventoryOptions{
+		Depth:          InventoryDepthDetails,
+		Concurrency:    2,
+		IncludeProject: func(p *Project) bool { return !strings.HasPrefix(p.Name, "skipped") },
+		IncludeService: func(s *Service) bool { return s.Type == "pg" },
+	}, "org-1")
+	require.NoError(t, err)
+	require.Len(t, inv.Organizations, 1)
+
+	org := inv.Organizations[0]
+	assert.Equal(t, "test-org", org.Name)
+	assert.Equal(t, []InventoryUser{{UserID: "u-1", Email: "test@aiven.io", State: "active"}}, org.Users)
+	require.Len(t, org.BillingGroups, 1)
+	assert.Equal(t, "bg-1", org.BillingGroups[0].ID)
+	require.Len(t, org.BillingGroups[0].Projects, 1)
+	require.Len(t, org.Projects, 1)
+	assert.Equal(t, "test-pr-b", org.Projects[0].Name)
+
+	project := org.BillingGroups[0].Projects[0]
+	assert.Equal(t, "test-pr-a", project.Name)
+	assert.Equal(t, []InventoryVPC{{ProjectVPCID: "vpc-1", CloudName: "aws-eu-west-1", NetworkCIDR: "10.0.0.0/24"}}, project.VPCs)
+	require.Len(t, project.Services, 1)
+	assert.Equal(t, []InventoryServiceUser{{Username: "avnadmin", Type: "primary"}}, project.Services[0].Users)
+	require.Len(t, project.Services[0].Integrations, 1)
+	assert.Equal(t, "si-1", project.Services[0].Integrations[0].ID)
+
+	b, err := json.Marshal(inv)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "secret")
+
+	shallow, err := c.Crawl(context.Background(), InventoryOptions{}, "org-1")
+	require.NoError(t, err)
+	assert.Empty(t, shallow.Organizations[0].BillingGroups[0].Projects[0].Services)
+}
+
+func Test_forEachConcurrently(t *testing.T) {
+	var inFlight, maxInFlight int32
+	err := forEachConcurrently(context.Background(), 10, 3, func(_ context.Context, _ int) error {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, maxInFlight, int32(3))
+
+	wantErr := errors.New("boom")
+	err = forEachConcurrently(context.Background(), 10, 1, func(_ context.Context, i int) error {
+		if i == 2 {
+			return wantErr
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, wantErr)
+}
@@ -0,0 +1,102 @@
+package aiven
+
+import "strings"
+
+// openSearchACLPermissionRank orders the permission levels from the most restrictive.
+var openSearchACLPermissionRank = map[string]int{
+	OpenSearchACLPermissionDeny:      0,
+	OpenSearchACLPermissionRead:      1,
+	OpenSearchACLPermissionWrite:     2,
+	OpenSearchACLPermissionReadWrite: 3,
+	OpenSearchACLPermissionAdmin:     4,
+}
+
+// matchOpenSearchPattern reports whether s matches the pattern, where * matches any sequence of characters,
+// ? any single character, and the _all pattern every name.
+func matchOpenSearchPattern(pattern, s string) bool {
+	if pattern == "_all" {
+		return true
+	}
+
+	// Iterative glob matching, backtracking to the last star on a mismatch
+	p, i, star, mark := 0, 0, -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case star >= 0:
+			mark++
+			p, i = star+1, mark
+		default:
+			return false
+		}
+	}
+
+	return strings.Trim(pattern[p:], "*") == ""
+}
+
+// openSearchPatternSpecificity ranks the patterns by the number of literal characters they have.
+func openSearchPatternSpecificity(pattern string) int {
+	if pattern == "_all" {
+		return 0
+	}
+
+	return len(pattern) - strings.Count(pattern, "*")
+}
+
+// Permission returns the permission level of the user on the index: the rule with the most specific
+// matching index pattern wins, the one with the most literal characters, whatever the order of the rules.
+// On a tie the more restrictive rule wins, and deny applies when no rule matches. Usernames of the ACLs
+// may be patterns too. Every user is admin when the ACLs are disabled.
+func (conf *OpenSearchACLConfig) Permission(username, index string) string {
+	if !conf.Enabled {
+		return OpenSearchACLPermissionAdmin
+	}
+
+	permission, specificity := OpenSearchACLPermissionDeny, -1
+	for _, acl := range conf.ACLs {
+		if !matchOpenSearchPattern(acl.Username, username) {
+			continue
+		}
+
+		for _, rule := range acl.Rules {
+			if _, ok := openSearchACLPermissionRank[rule.Permission]; !ok || !matchOpenSearchPattern(rule.Index, index) {
+				continue
+			}
+
+			s := openSearchPatternSpecificity(rule.Index)
+			if s > specificity || s == specificity && openSearchACLPermissionRank[rule.Permission] < openSearchACLPermissionRank[permission] {
+				permission, specificity = rule.Permission, s
+			}
+		}
+	}
+
+	return permission
+}
+
+// Allows reports whether the permission of the user on the index includes the given one,
+// e.g. readwrite includes read and write, and admin includes every permission. Deny is not
+// a permission which can be allowed, it and unknown permissions are never allowed, use
+// Permission to find out whether the user is denied.
+func (conf *OpenSearchACLConfig) Allows(username, index, permission string) bool {
+	if _, ok := openSearchACLPermissionRank[permission]; !ok || permission == OpenSearchACLPermissionDeny {
+		return false
+	}
+
+	got := conf.Permission(username, index)
+	return got != OpenSearchACLPermissionDeny && openSearchACLPermissionIncludes(got, permission)
+}
+
+// CanRead reports whether the user can read the index.
+func (conf *OpenSearchACLConfig) CanRead(username, index string) bool {
+	return conf.Allows(username, index, OpenSearchACLPermissionRead)
+}
+
+// CanWrite reports whether the user can write the index.
+func (conf *OpenSearchACLConfig) CanWrite(username, index string) bool {
+	return conf.Allows(username, index, OpenSearchACLPermissionWrite)
+}
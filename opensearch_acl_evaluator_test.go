@@ -0,0 +1,138 @@
+package aiven
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_matchOpenSearchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"logs-*", "logs-2024.01", true},
+		{"logs-*", "logs", false},
+		{"*", "", true},
+		{"_all", "anything", true},
+		{"logs-*-prod", "logs-app-prod", true},
+		{"logs-*-prod", "logs-app-prod-eu", false},
+		{"log?", "logs", true},
+		{"a*b*c", "aXbYbZc", true},
+		{"a*", "a*b", true},
+		{"orders", "orders", true},
+		{"orders", "orders2", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchOpenSearchPattern(tt.pattern, tt.s), "%s %s", tt.pattern, tt.s)
+	}
+}
+
+func TestOpenSearchACLConfig_Permission(t *testing.T) {
+	conf := &OpenSearchACLConfig{
+		Enabled: true,
+		ACLs: []OpenSearchACL{
+			{Username: "analyst", Rules: []OpenSearchACLRule{
+				{Index: "_all", Permission: OpenSearchACLPermissionRead},
+				{Index: "logs-*", Permission: OpenSearchACLPermissionReadWrite},
+				{Index: "logs-secret*", Permission: OpenSearchACLPermissionDeny},
+				{Index: "metrics-*", Permission: OpenSearchACLPermissionAdmin},
+				{Index: "metrics-?", Permission: OpenSearchACLPermissionWrite},
+			}},
+			{Username: "svc-*", Rules: []OpenSearchACLRule{{Index: "events", Permission: OpenSearchACLPermissionWrite}}},
+		},
+	}
+
+	tests := []struct {
+		username string
+		index    string
+		want     string
+		read     bool
+		write    bool
+	}{
+		{"analyst", "orders", OpenSearchACLPermissionRead, true, false},
+		{"analyst", "logs-app", OpenSearchACLPermissionReadWrite, true, true},
+		{"analyst", "logs-secret-1", OpenSearchACLPermissionDeny, false, false},
+		{"analyst", "metrics-a", OpenSearchACLPermissionWrite, false, true},
+		{"analyst", "metrics-ab", OpenSearchACLPermissionAdmin, true, true},
+		{"svc-ingest", "events", OpenSearchACLPermissionWrite, false, true},
+		{"svc-ingest", "orders", OpenSearchACLPermissionDeny, false, false},
+		{"nobody", "orders", OpenSearchACLPermissionDeny, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.username+"/"+tt.index, func(t *testing.T) {
+			assert.Equal(t, tt.want, conf.Permission(tt.username, tt.index))
+			assert.Equal(t, tt.read, conf.CanRead(tt.username, tt.index))
+			assert.Equal(t, tt.write, conf.CanWrite(tt.username, tt.index))
+		})
+	}
+
+	assert.False(t, conf.Allows("analyst", "logs-app", OpenSearchACLPermissionAdmin))
+	assert.False(t, conf.Allows("analyst", "logs-secret-1", OpenSearchACLPermissionDeny))
+	assert.False(t, conf.Allows("analyst", "orders", OpenSearchACLPermissionDeny))
+	assert.False(t, conf.Allows("analyst", "metrics-ab", "superuser"))
+
+	conf.Enabled = false
+	assert.True(t, conf.Allows("nobody", "orders", OpenSearchACLPermissionAdmin))
+}
+
+func TestOpenSearchACLConfig_Permission_ordering(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []OpenSearchACLRule
+		index string
+		want  string
+	}{
+		{
+			name: "more specific wins over a higher permission",
+			rules: []OpenSearchACLRule{
+				{Index: "*", Permission: OpenSearchACLPermissionAdmin},
+				{Index: "logs", Permission: OpenSearchACLPermissionRead},
+			},
+			index: "logs",
+			want:  OpenSearchACLPermissionRead,
+		},
+		{
+			name: "more specific wins over a lower permission",
+			rules: []OpenSearchACLRule{
+				{Index: "logs-*", Permission: OpenSearchACLPermissionRead},
+				{Index: "logs-app-*", Permission: OpenSearchACLPermissionAdmin},
+			},
+			index: "logs-app-1",
+			want:  OpenSearchACLPermissionAdmin,
+		},
+		{
+			name: "more specific deny",
+			rules: []OpenSearchACLRule{
+				{Index: "_all", Permission: OpenSearchACLPermissionReadWrite},
+				{Index: "secret*", Permission: OpenSearchACLPermissionDeny},
+			},
+			index: "secret-1",
+			want:  OpenSearchACLPermissionDeny,
+		},
+		{
+			name: "more restrictive wins a tie",
+			rules: []OpenSearchACLRule{
+				{Index: "logs-a*", Permission: OpenSearchACLPermissionAdmin},
+				{Index: "logs-*b", Permission: OpenSearchACLPermissionRead},
+			},
+			index: "logs-ab",
+			want:  OpenSearchACLPermissionRead,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The order of the rules does not matter
+			reversed := make([]OpenSearchACLRule, len(tt.rules))
+			for i, r := range tt.rules {
+				reversed[len(tt.rules)-1-i] = r
+			}
+
+			for _, rules := range [][]OpenSearchACLRule{tt.rules, reversed} {
+				conf := &OpenSearchACLConfig{Enabled: true, ACLs: []OpenSearchACL{{Username: "u", Rules: rules}}}
+				assert.Equal(t, tt.want, conf.Permission("u", tt.index))
+			}
+		})
+	}
+}
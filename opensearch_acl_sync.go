@@ -0,0 +1,144 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// openSearchACLSyncAttempts is the number of times Sync recomputes the change after a concurrent update.
+const openSearchACLSyncAttempts = 3
+
+// ErrOpenSearchACLConcurrentUpdate is returned when the ACL config keeps changing while Sync applies a change.
+var ErrOpenSearchACLConcurrentUpdate = errors.New("opensearch acl config was updated concurrently")
+
+type (
+	// OpenSearchACLSyncResult is the change applied by Sync, the rules are grouped by user.
+	OpenSearchACLSyncResult struct {
+		Changed bool
+		Added   []OpenSearchACL
+		Removed []OpenSearchACL
+		Config  OpenSearchACLConfig
+	}
+)
+
+// openSearchACLRuleSet indexes the rules of a config by user.
+func openSearchACLRuleSet(conf OpenSearchACLConfig) map[string]map[OpenSearchACLRule]bool {
+	set := make(map[string]map[OpenSearchACLRule]bool)
+	for _, acl := range conf.ACLs {
+		if set[acl.Username] == nil {
+			set[acl.Username] = make(map[OpenSearchACLRule]bool)
+		}
+		for _, rule := range acl.Rules {
+			set[acl.Username][rule] = true
+		}
+	}
+
+	return set
+}
+
+// mergeOpenSearchACLs returns the desired config written as a minimal change of current: the users and rules
+// kept stay in their current order, the new ones are appended in their desired order.
+func mergeOpenSearchACLs(current, desired OpenSearchACLConfig) (OpenSearchACLConfig, []OpenSearchACL, []OpenSearchACL) {
+	currentSet := openSearchACLRuleSet(current)
+	desiredSet := openSearchACLRuleSet(desired)
+
+	merged := OpenSearchACLConfig{ACLs: []OpenSearchACL{}, Enabled: desired.Enabled, ExtendedAcl: desired.ExtendedAcl}
+	index := make(map[string]int)
+	var added, removed []OpenSearchACL
+
+	for _, acl := range current.ACLs {
+		var kept, dropped []OpenSearchACLRule
+		for _, rule := range acl.Rules {
+			if desiredSet[acl.Username][rule] {
+				kept = append(kept, rule)
+			} else {
+				dropped = append(dropped, rule)
+			}
+		}
+
+		if len(dropped) > 0 {
+			removed = append(removed, OpenSearchACL{Username: acl.Username, Rules: dropped})
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		if i, ok := index[acl.Username]; ok {
+			merged.ACLs[i].Rules = append(merged.ACLs[i].Rules, kept...)
+			continue
+		}
+		index[acl.Username] = len(merged.ACLs)
+		merged.ACLs = append(merged.ACLs, OpenSearchACL{Username: acl.Username, Rules: kept})
+	}
+
+	seen := make(map[string]map[OpenSearchACLRule]bool)
+	for _, acl := range desired.ACLs {
+		var rules []OpenSearchACLRule
+		for _, rule := range acl.Rules {
+			if currentSet[acl.Username][rule] || seen[acl.Username][rule] {
+				continue
+			}
+			if seen[acl.Username] == nil {
+				seen[acl.Username] = make(map[OpenSearchACLRule]bool)
+			}
+			seen[acl.Username][rule] = true
+			rules = append(rules, rule)
+		}
+
+		if len(rules) == 0 {
+			continue
+		}
+		added = append(added, OpenSearchACL{Username: acl.Username, Rules: rules})
+		if i, ok := index[acl.Username]; ok {
+			merged.ACLs[i].Rules = append(merged.ACLs[i].Rules, rules...)
+			continue
+		}
+		index[acl.Username] = len(merged.ACLs)
+		merged.ACLs = append(merged.ACLs, OpenSearchACL{Username: acl.Username, Rules: rules})
+	}
+
+	return merged, added, removed
+}
+
+// Sync makes the ACL config of the service match desired with a minimal change. It re-reads the config
+// before the update and recomputes the change when it was modified in between, failing with
+// ErrOpenSearchACLConcurrentUpdate when it keeps changing.
+func (h *OpenSearchACLsHandler) Sync(ctx context.Context, project, service string, desired OpenSearchACLConfig) (*OpenSearchACLSyncResult, error) {
+	r, err := h.Get(ctx, project, service)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < openSearchACLSyncAttempts; attempt++ {
+		current := r.OpenSearchACLConfig
+		merged, added, removed := mergeOpenSearchACLs(current, desired)
+		result := &OpenSearchACLSyncResult{
+			Changed: len(added) > 0 || len(removed) > 0 || current.Enabled != desired.Enabled || current.ExtendedAcl != desired.ExtendedAcl,
+			Added:   added,
+			Removed: removed,
+			Config:  current,
+		}
+		if !result.Changed {
+			return result, nil
+		}
+
+		// Optimistic concurrency, the API has no version to update against
+		r, err = h.Get(ctx, project, service)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(r.OpenSearchACLConfig, current) {
+			continue
+		}
+
+		u, err := h.Update(ctx, project, service, OpenSearchACLRequest{OpenSearchACLConfig: merged})
+		if err != nil {
+			return nil, err
+		}
+
+		result.Config = u.OpenSearchACLConfig
+		return result, nil
+	}
+
+	return nil, ErrOpenSearchACLConcurrentUpdate
+}
@@ -0,0 +1,103 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mergeOpenSearchACLs(t *testing.T) {
+	current := OpenSearchACLConfig{Enabled: true, ACLs: []OpenSearchACL{
+		{Username: "a", Rules: []OpenSearchACLRule{{Index: "x", Permission: "read"}, {Index: "y", Permission: "write"}}},
+		{Username: "b", Rules: []OpenSearchACLRule{{Index: "x", Permission: "admin"}}},
+		{Username: "c", Rules: []OpenSearchACLRule{{Index: "z", Permission: "read"}}},
+	}}
+	desired := OpenSearchACLConfig{Enabled: true, ACLs: []OpenSearchACL{
+		{Username: "d", Rules: []OpenSearchACLRule{{Index: "*", Permission: "read"}}},
+		{Username: "c", Rules: []OpenSearchACLRule{{Index: "z", Permission: "read"}}},
+		{Username: "a", Rules: []OpenSearchACLRule{{Index: "z", Permission: "read"}, {Index: "x", Permission: "read"}}},
+	}}
+
+	merged, added, removed := mergeOpenSearchACLs(current, desired)
+	assert.Equal(t, OpenSearchACLConfig{Enabled: true, ACLs: []OpenSearchACL{
+		{Username: "a", Rules: []OpenSearchACLRule{{Index: "x", Permission: "read"}, {Index: "z", Permission: "read"}}},
+		{Username: "c", Rules: []OpenSearchACLRule{{Index: "z", Permission: "read"}}},
+		{Username: "d", Rules: []OpenSearchACLRule{{Index: "*", Permission: "read"}}},
+	}}, merged)
+	assert.Equal(t, []OpenSearchACL{
+		{Username: "d", Rules: []OpenSearchACLRule{{Index: "*", Permission: "read"}}},
+		{Username: "a", Rules: []OpenSearchACLRule{{Index: "z", Permission: "read"}}},
+	}, added)
+	assert.Equal(t, []OpenSearchACL{
+		{Username: "a", Rules: []OpenSearchACLRule{{Index: "y", Permission: "write"}}},
+		{Username: "b", Rules: []OpenSearchACLRule{{Index: "x", Permission: "admin"}}},
+	}, removed)
+}
+
+func TestOpenSearchACLsHandler_Sync(t *testing.T) {
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+	)
+
+	config := OpenSearchACLConfig{Enabled: true, ACLs: []OpenSearchACL{
+		{Username: "a", Rules: []OpenSearchACLRule{{Index: "x", Permission: "read"}}},
+	}}
+	gets, updates := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		switch {
+		case r.URL.Path == "/userauth":
+			_ = json.NewEncoder(w).Encode(authResponse{Token: AccessToken, State: "active"})
+			return
+		case r.URL.Path != "/project/test-pr/service/test-sr/opensearch/acl":
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		case r.Method == http.MethodGet:
+			gets++
+			// Someone else adds a rule between the first read and the check before the update
+			if gets == 2 {
+				config.ACLs = append(config.ACLs, OpenSearchACL{Username: "b", Rules: []OpenSearchACLRule{{Index: "y", Permission: "read"}}})
+			}
+		case r.Method == http.MethodPut:
+			updates++
+			var req OpenSearchACLRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			config = req.OpenSearchACLConfig
+		}
+		_ = json.NewEncoder(w).Encode(OpenSearchACLResponse{OpenSearchACLConfig: config})
+	}))
+	defer ts.Close()
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	require.NoError(t, err)
+
+	desired := OpenSearchACLConfig{Enabled: true, ACLs: []OpenSearchACL{
+		{Username: "a", Rules: []OpenSearchACLRule{{Index: "x", Permission: "readwrite"}}},
+	}}
+
+	ctx := context.Background()
+	got, err := c.OpenSearchACLs.Sync(ctx, "test-pr", "test-sr", desired)
+	require.NoError(t, err)
+	assert.True(t, got.Changed)
+	assert.Equal(t, 3, gets)
+	assert.Equal(t, 1, updates)
+	assert.Equal(t, []OpenSearchACL{
+		{Username: "a", Rules: []OpenSearchACLRule{{Index: "x", Permission: "read"}}},
+		{Username: "b", Rules: []OpenSearchACLRule{{Index: "y", Permission: "read"}}},
+	}, got.Removed)
+	assert.Equal(t, desired, got.Config)
+
+	got, err = c.OpenSearchACLs.Sync(ctx, "test-pr", "test-sr", desired)
+	require.NoError(t, err)
+	assert.False(t, got.Changed)
+	assert.Equal(t, 1, updates)
+}
@@ -0,0 +1,571 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// OpenSearchACLPermissionDeny denies any access to the matching indexes.
+	OpenSearchACLPermissionDeny = "deny"
+	// OpenSearchACLPermissionRead allows searching and reading the documents of the matching indexes.
+	OpenSearchACLPermissionRead = "read"
+	// OpenSearchACLPermissionWrite allows creating the matching indexes and writing their documents.
+	OpenSearchACLPermissionWrite = "write"
+	// OpenSearchACLPermissionReadWrite combines OpenSearchACLPermissionRead and OpenSearchACLPermissionWrite.
+	OpenSearchACLPermissionReadWrite = "readwrite"
+	// OpenSearchACLPermissionAdmin allows every operation on the matching indexes, including deleting them.
+	OpenSearchACLPermissionAdmin = "admin"
+)
+
+// ErrOpenSearchACLNotConvertible is returned when ACL rules have no security plugin equivalent.
+var ErrOpenSearchACLNotConvertible = errors.New("opensearch acl rules cannot be converted to the security plugin")
+
+// openSearchACLActionGroups are the security plugin action groups granting the index permissions of an ACL rule.
+var openSearchACLActionGroups = map[string][]string{
+	OpenSearchACLPermissionRead:      {"read"},
+	OpenSearchACLPermissionWrite:     {"write", "create_index"},
+	OpenSearchACLPermissionReadWrite: {"read", "write", "create_index"},
+	OpenSearchACLPermissionAdmin:     {"indices_all"},
+}
+
+type (
+	// OpenSearchInternalUsersHandler is the handler of the internal users of the OpenSearch Security Plugin.
+	OpenSearchInternalUsersHandler struct {
+		client *Client
+	}
+
+	// OpenSearchRolesHandler is the handler of the roles of the OpenSearch Security Plugin.
+	OpenSearchRolesHandler struct {
+		client *Client
+	}
+
+	// OpenSearchRoleMappingsHandler is the handler of the role mappings of the OpenSearch Security Plugin.
+	OpenSearchRoleMappingsHandler struct {
+		client *Client
+	}
+
+	// OpenSearchInternalUser is an internal user of the OpenSearch Security Plugin.
+	OpenSearchInternalUser struct {
+		// Password is only sent, the plugin reports the Hash instead. SyncSecurityConfig cannot compare it
+		// to the current one, it does not update an existing user for a new password alone, change the
+		// password with OpenSearchInternalUsersHandler.Put.
+		Password     string            `json:"password,omitempty"`
+		Hash         string            `json:"hash,omitempty"`
+		BackendRoles []string          `json:"backend_roles,omitempty"`
+		Attributes   map[string]string `json:"attributes,omitempty"`
+		Description  string            `json:"description,omitempty"`
+		Reserved     bool              `json:"reserved,omitempty"`
+		Hidden       bool              `json:"hidden,omitempty"`
+		Static       bool              `json:"static,omitempty"`
+	}
+
+	// OpenSearchIndexPermission grants actions on the indexes matching the patterns.
+	OpenSearchIndexPermission struct {
+		IndexPatterns  []string `json:"index_patterns"`
+		DLS            string   `json:"dls,omitempty"`
+		FLS            []string `json:"fls,omitempty"`
+		MaskedFields   []string `json:"masked_fields,omitempty"`
+		AllowedActions []string `json:"allowed_actions"`
+	}
+
+	// OpenSearchTenantPermission grants actions on the tenants matching the patterns.
+	OpenSearchTenantPermission struct {
+		TenantPatterns []string `json:"tenant_patterns"`
+		AllowedActions []string `json:"allowed_actions"`
+	}
+
+	// OpenSearchRole is a role of the OpenSearch Security Plugin.
+	OpenSearchRole struct {
+		Description        string                       `json:"description,omitempty"`
+		ClusterPermissions []string                     `json:"cluster_permissions,omitempty"`
+		IndexPermissions   []OpenSearchIndexPermission  `json:"index_permissions,omitempty"`
+		TenantPermissions  []OpenSearchTenantPermission `json:"tenant_permissions,omitempty"`
+		Reserved           bool                         `json:"reserved,omitempty"`
+		Hidden             bool                         `json:"hidden,omitempty"`
+		Static             bool                         `json:"static,omitempty"`
+	}
+
+	// OpenSearchRoleMapping maps users, backend roles and hosts to a role of the OpenSearch Security Plugin.
+	OpenSearchRoleMapping struct {
+		Description     string   `json:"description,omitempty"`
+		BackendRoles    []string `json:"backend_roles,omitempty"`
+		AndBackendRoles []string `json:"and_backend_roles,omitempty"`
+		Hosts           []string `json:"hosts,omitempty"`
+		Users           []string `json:"users,omitempty"`
+		Reserved        bool     `json:"reserved,omitempty"`
+		Hidden          bool     `json:"hidden,omitempty"`
+	}
+
+	// OpenSearchSecurityConfig is the security configuration managed by SyncSecurityConfig, keyed by name.
+	// A nil section is not managed.
+	OpenSearchSecurityConfig struct {
+		InternalUsers map[string]OpenSearchInternalUser
+		Roles         map[string]OpenSearchRole
+		RoleMappings  map[string]OpenSearchRoleMapping
+	}
+
+	// OpenSearchSecuritySyncResult lists the entries changed by SyncSecurityConfig, e.g. roles/readers.
+	OpenSearchSecuritySyncResult struct {
+		Created []string
+		Updated []string
+		Deleted []string
+	}
+
+	// openSearchSecurityChange is a pending change of an entry of the security configuration.
+	openSearchSecurityChange struct {
+		kind  string
+		name  string
+		value interface{}
+	}
+)
+
+// openSearchSecurityAPIPath builds the path of the security plugin REST API proxied by the service.
+func openSearchSecurityAPIPath(project, service string, parts ...string) string {
+	return buildPath(append([]string{"project", project, "service", service, "opensearch", "proxy", "_plugins", "_security", "api"}, parts...)...)
+}
+
+// getOpenSearchSecurity decodes a security plugin resource, which is an object keyed by name.
+func (c *Client) getOpenSearchSecurity(ctx context.Context, project, service string, out interface{}, parts ...string) error {
+	bts, err := c.doGetRequest(ctx, openSearchSecurityAPIPath(project, service, parts...), nil)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bts, out)
+}
+
+// putOpenSearchSecurity creates or replaces a security plugin resource.
+func (c *Client) putOpenSearchSecurity(ctx context.Context, project, service string, req interface{}, parts ...string) error {
+	bts, err := c.doPutRequest(ctx, openSearchSecurityAPIPath(project, service, parts...), req)
+	if err != nil {
+		return err
+	}
+
+	return checkAPIResponse(bts, nil)
+}
+
+// deleteOpenSearchSecurity deletes a security plugin resource.
+func (c *Client) deleteOpenSearchSecurity(ctx context.Context, project, service string, parts ...string) error {
+	bts, err := c.doDeleteRequest(ctx, openSearchSecurityAPIPath(project, service, parts...), nil)
+	if err != nil {
+		return err
+	}
+
+	return checkAPIResponse(bts, nil)
+}
+
+// openSearchSecurityNotFound is the error of a missing security plugin resource.
+func openSearchSecurityNotFound(kind, name string) error {
+	return Error{Message: fmt.Sprintf("OpenSearch %s %s not found", kind, name), Status: 404}
+}
+
+// List lists the internal users
+func (h *OpenSearchInternalUsersHandler) List(ctx context.Context, project, service string) (map[string]OpenSearchInternalUser, error) {
+	var r map[string]OpenSearchInternalUser
+	return r, h.client.getOpenSearchSecurity(ctx, project, service, &r, "internalusers")
+}
+
+// Get gets an internal user
+func (h *OpenSearchInternalUsersHandler) Get(ctx context.Context, project, service, name string) (*OpenSearchInternalUser, error) {
+	var r map[string]OpenSearchInternalUser
+	if err := h.client.getOpenSearchSecurity(ctx, project, service, &r, "internalusers", name); err != nil {
+		return nil, err
+	}
+
+	u, ok := r[name]
+	if !ok {
+		return nil, openSearchSecurityNotFound("internal user", name)
+	}
+
+	return &u, nil
+}
+
+// Put creates or replaces an internal user, an existing user keeps its password when Password is empty
+func (h *OpenSearchInternalUsersHandler) Put(ctx context.Context, project, service, name string, user OpenSearchInternalUser) error {
+	return h.client.putOpenSearchSecurity(ctx, project, service, user.writable(), "internalusers", name)
+}
+
+// Delete deletes an internal user
+func (h *OpenSearchInternalUsersHandler) Delete(ctx context.Context, project, service, name string) error {
+	return h.client.deleteOpenSearchSecurity(ctx, project, service, "internalusers", name)
+}
+
+// List lists the roles
+func (h *OpenSearchRolesHandler) List(ctx context.Context, project, service string) (map[string]OpenSearchRole, error) {
+	var r map[string]OpenSearchRole
+	return r, h.client.getOpenSearchSecurity(ctx, project, service, &r, "roles")
+}
+
+// Get gets a role
+func (h *OpenSearchRolesHandler) Get(ctx context.Context, project, service, name string) (*OpenSearchRole, error) {
+	var r map[string]OpenSearchRole
+	if err := h.client.getOpenSearchSecurity(ctx, project, service, &r, "roles", name); err != nil {
+		return nil, err
+	}
+
+	role, ok := r[name]
+	if !ok {
+		return nil, openSearchSecurityNotFound("role", name)
+	}
+
+	return &role, nil
+}
+
+// Put creates or replaces a role
+func (h *OpenSearchRolesHandler) Put(ctx context.Context, project, service, name string, role OpenSearchRole) error {
+	return h.client.putOpenSearchSecurity(ctx, project, service, role.writable(), "roles", name)
+}
+
+// Delete deletes a role
+func (h *OpenSearchRolesHandler) Delete(ctx context.Context, project, service, name string) error {
+	return h.client.deleteOpenSearchSecurity(ctx, project, service, "roles", name)
+}
+
+// List lists the role mappings
+func (h *OpenSearchRoleMappingsHandler) List(ctx context.Context, project, service string) (map[string]OpenSearchRoleMapping, error) {
+	var r map[string]OpenSearchRoleMapping
+	return r, h.client.getOpenSearchSecurity(ctx, project, service, &r, "rolesmapping")
+}
+
+// Get gets the mapping of a role
+func (h *OpenSearchRoleMappingsHandler) Get(ctx context.Context, project, service, role string) (*OpenSearchRoleMapping, error) {
+	var r map[string]OpenSearchRoleMapping
+	if err := h.client.getOpenSearchSecurity(ctx, project, service, &r, "rolesmapping", role); err != nil {
+		return nil, err
+	}
+
+	m, ok := r[role]
+	if !ok {
+		return nil, openSearchSecurityNotFound("role mapping", role)
+	}
+
+	return &m, nil
+}
+
+// Put creates or replaces the mapping of a role
+func (h *OpenSearchRoleMappingsHandler) Put(ctx context.Context, project, service, role string, mapping OpenSearchRoleMapping) error {
+	return h.client.putOpenSearchSecurity(ctx, project, service, mapping.writable(), "rolesmapping", role)
+}
+
+// Delete deletes the mapping of a role
+func (h *OpenSearchRoleMappingsHandler) Delete(ctx context.Context, project, service, role string) error {
+	return h.client.deleteOpenSearchSecurity(ctx, project, service, "rolesmapping", role)
+}
+
+// writable drops the fields the plugin reports but does not accept.
+func (u OpenSearchInternalUser) writable() OpenSearchInternalUser {
+	u.Hash, u.Reserved, u.Hidden, u.Static = "", false, false, false
+	return u
+}
+
+// writable drops the fields the plugin reports but does not accept.
+func (r OpenSearchRole) writable() OpenSearchRole {
+	r.Reserved, r.Hidden, r.Static = false, false, false
+	return r
+}
+
+// writable drops the fields the plugin reports but does not accept.
+func (m OpenSearchRoleMapping) writable() OpenSearchRoleMapping {
+	m.Reserved, m.Hidden = false, false
+	return m
+}
+
+// openSearchSecurityEqual compares two entries by their JSON, so nil and empty lists are equal.
+func openSearchSecurityEqual(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// diffOpenSearchSecurity returns the entries to put and delete so that current matches desired.
+// Reserved, hidden and static entries are never changed, and passwords only count for new users.
+func diffOpenSearchSecurity(current, desired OpenSearchSecurityConfig) (created, updated, deleted []openSearchSecurityChange) {
+	for name, d := range desired.Roles {
+		c, ok := current.Roles[name]
+		switch {
+		case !ok:
+			created = append(created, openSearchSecurityChange{"roles", name, d})
+		case c.Reserved || c.Hidden || c.Static:
+		case !openSearchSecurityEqual(c.writable(), d.writable()):
+			updated = append(updated, openSearchSecurityChange{"roles", name, d})
+		}
+	}
+	for name, d := range desired.InternalUsers {
+		c, ok := current.InternalUsers[name]
+		switch {
+		case !ok:
+			created = append(created, openSearchSecurityChange{"internalusers", name, d})
+		case c.Reserved || c.Hidden || c.Static:
+		default:
+			c, cmp := c.writable(), d.writable()
+			c.Password, cmp.Password = "", ""
+			if !openSearchSecurityEqual(c, cmp) {
+				updated = append(updated, openSearchSecurityChange{"internalusers", name, d})
+			}
+		}
+	}
+	for name, d := range desired.RoleMappings {
+		c, ok := current.RoleMappings[name]
+		switch {
+		case !ok:
+			created = append(created, openSearchSecurityChange{"rolesmapping", name, d})
+		case c.Reserved || c.Hidden:
+		case !openSearchSecurityEqual(c.writable(), d.writable()):
+			updated = append(updated, openSearchSecurityChange{"rolesmapping", name, d})
+		}
+	}
+
+	// A nil section is not managed, only the entries of the managed ones are pruned
+	if desired.RoleMappings != nil {
+		for name, c := range current.RoleMappings {
+			if _, ok := desired.RoleMappings[name]; !ok && !c.Reserved && !c.Hidden {
+				deleted = append(deleted, openSearchSecurityChange{kind: "rolesmapping", name: name})
+			}
+		}
+	}
+	if desired.InternalUsers != nil {
+		for name, c := range current.InternalUsers {
+			if _, ok := desired.InternalUsers[name]; !ok && !c.Reserved && !c.Hidden && !c.Static {
+				deleted = append(deleted, openSearchSecurityChange{kind: "internalusers", name: name})
+			}
+		}
+	}
+	if desired.Roles != nil {
+		for name, c := range current.Roles {
+			if _, ok := desired.Roles[name]; !ok && !c.Reserved && !c.Hidden && !c.Static {
+				deleted = append(deleted, openSearchSecurityChange{kind: "roles", name: name})
+			}
+		}
+	}
+
+	// Roles before users before mappings, so a mapping never refers to a missing role; deletions in reverse
+	order := map[string]int{"roles": 0, "internalusers": 1, "rolesmapping": 2}
+	byOrder := func(changes []openSearchSecurityChange, reverse bool) {
+		sort.Slice(changes, func(i, j int) bool {
+			if changes[i].kind != changes[j].kind {
+				return (order[changes[i].kind] < order[changes[j].kind]) != reverse
+			}
+			return changes[i].name < changes[j].name
+		})
+	}
+	byOrder(created, false)
+	byOrder(updated, false)
+	byOrder(deleted, true)
+
+	return created, updated, deleted
+}
+
+// SyncSecurityConfig makes the internal users, roles and role mappings of the service match desired,
+// leaving the reserved, hidden and static entries untouched. A nil section of desired is left as is,
+// while an empty one deletes every entry of the section. Existing users keep their password
+// unless another of their fields changes and Password is set.
+func (h *OpenSearchSecurityPluginHandler) SyncSecurityConfig(ctx context.Context, project, service string, desired OpenSearchSecurityConfig) (*OpenSearchSecuritySyncResult, error) {
+	var current OpenSearchSecurityConfig
+	if desired.InternalUsers != nil {
+		if err := h.client.getOpenSearchSecurity(ctx, project, service, &current.InternalUsers, "internalusers"); err != nil {
+			return nil, err
+		}
+	}
+	if desired.Roles != nil {
+		if err := h.client.getOpenSearchSecurity(ctx, project, service, &current.Roles, "roles"); err != nil {
+			return nil, err
+		}
+	}
+	if desired.RoleMappings != nil {
+		if err := h.client.getOpenSearchSecurity(ctx, project, service, &current.RoleMappings, "rolesmapping"); err != nil {
+			return nil, err
+		}
+	}
+
+	created, updated, deleted := diffOpenSearchSecurity(current, desired)
+
+	put := func(c openSearchSecurityChange) error {
+		switch v := c.value.(type) {
+		case OpenSearchInternalUser:
+			return h.client.putOpenSearchSecurity(ctx, project, service, v.writable(), c.kind, c.name)
+		case OpenSearchRole:
+			return h.client.putOpenSearchSecurity(ctx, project, service, v.writable(), c.kind, c.name)
+		case OpenSearchRoleMapping:
+			return h.client.putOpenSearchSecurity(ctx, project, service, v.writable(), c.kind, c.name)
+		}
+		return fmt.Errorf("unsupported security entry %T", c.value)
+	}
+
+	result := &OpenSearchSecuritySyncResult{}
+	for _, c := range created {
+		if err := put(c); err != nil {
+			return result, err
+		}
+		result.Created = append(result.Created, c.kind+"/"+c.name)
+	}
+	for _, c := range updated {
+		if err := put(c); err != nil {
+			return result, err
+		}
+		result.Updated = append(result.Updated, c.kind+"/"+c.name)
+	}
+	for _, c := range deleted {
+		if err := h.client.deleteOpenSearchSecurity(ctx, project, service, c.kind, c.name); err != nil {
+			return result, err
+		}
+		result.Deleted = append(result.Deleted, c.kind+"/"+c.name)
+	}
+
+	return result, nil
+}
+
+// openSearchACLIndexPattern converts an ACL index pattern to a security plugin one.
+func openSearchACLIndexPattern(index string) string {
+	if index == "_all" {
+		return "*"
+	}
+
+	return index
+}
+
+// OpenSearchACLConversionOptions are the options of OpenSearchSecurityConfigFromACLs.
+type OpenSearchACLConversionOptions struct {
+	// SkipDenyRules leaves the deny rules out of the roles and reports them among the warnings.
+	// The security plugin cannot deny, so a deny rule fails the conversion unless it is set.
+	SkipDenyRules bool
+}
+
+// openSearchACLPermissionIncludes reports whether a permission includes another one,
+// e.g. readwrite includes read and write, and admin includes every permission.
+func openSearchACLPermissionIncludes(permission, other string) bool {
+	switch permission {
+	case OpenSearchACLPermissionAdmin:
+		return true
+	case OpenSearchACLPermissionReadWrite:
+		return other != OpenSearchACLPermissionAdmin
+	}
+
+	return permission == other
+}
+
+// openSearchPatternsOverlap reports whether an index name matches both patterns,
+// where * matches any sequence of characters, ? any single character, and _all every name.
+func openSearchPatternsOverlap(a, b string) bool {
+	if a == "_all" {
+		a = "*"
+	}
+	if b == "_all" {
+		b = "*"
+	}
+
+	seen := make(map[[2]int]bool)
+	var overlap func(i, j int) bool
+	overlap = func(i, j int) bool {
+		key := [2]int{i, j}
+		if done, ok := seen[key]; ok {
+			return done
+		}
+		seen[key] = false
+
+		var ok bool
+		switch {
+		case i < len(a) && a[i] == '*':
+			ok = overlap(i+1, j) || j < len(b) && overlap(i, j+1)
+		case j < len(b) && b[j] == '*':
+			ok = overlap(i, j+1) || i < len(a) && overlap(i+1, j)
+		case i == len(a) || j == len(b):
+			ok = i == len(a) && j == len(b)
+		default:
+			ok = (a[i] == '?' || b[j] == '?' || a[i] == b[j]) && overlap(i+1, j+1)
+		}
+
+		seen[key] = ok
+		return ok
+	}
+
+	return overlap(0, 0)
+}
+
+// openSearchACLEscalations lists the rules whose permission the ACLs limit on some indexes with a more
+// specific rule, which the security plugin would grant anyway as it unions the permissions of a role.
+func openSearchACLEscalations(acl OpenSearchACL, rules []OpenSearchACLRule) []string {
+	var problems []string
+	for _, wide := range rules {
+		for _, narrow := range rules {
+			sw, sn := openSearchPatternSpecificity(wide.Index), openSearchPatternSpecificity(narrow.Index)
+			wins := sn > sw || sn == sw && openSearchACLPermissionRank[narrow.Permission] < openSearchACLPermissionRank[wide.Permission]
+			if !wins || openSearchACLPermissionIncludes(narrow.Permission, wide.Permission) ||
+				!openSearchPatternsOverlap(wide.Index, narrow.Index) {
+				continue
+			}
+
+			problems = append(problems, fmt.Sprintf("user %s: %s permission on %s is limited to %s on %s",
+				acl.Username, wide.Permission, wide.Index, narrow.Permission, narrow.Index))
+		}
+	}
+
+	return problems
+}
+
+// OpenSearchSecurityConfigFromACLs converts an ACL config into a role named acl_<username> per user,
+// mapped to that user, to ease the migration to the security plugin. Only the roles and role mappings
+// are managed, the internal users are left nil so that SyncSecurityConfig keeps them. Rules the security
+// plugin cannot express fail with ErrOpenSearchACLNotConvertible, see OpenSearchACLConversionOptions:
+// deny rules, and a more specific rule with a lower permission than an overlapping one, which the ACLs
+// apply while the security plugin grants the union of both.
+func OpenSearchSecurityConfigFromACLs(conf OpenSearchACLConfig, opts *OpenSearchACLConversionOptions) (*OpenSearchSecurityConfig, []string, error) {
+	if opts == nil {
+		opts = &OpenSearchACLConversionOptions{}
+	}
+
+	result := &OpenSearchSecurityConfig{
+		Roles:        make(map[string]OpenSearchRole),
+		RoleMappings: make(map[string]OpenSearchRoleMapping),
+	}
+
+	var warnings, problems []string
+	if !conf.Enabled {
+		warnings = append(warnings, "ACLs are disabled, every user has full access until roles are mapped")
+	}
+
+	for _, acl := range conf.ACLs {
+		role := OpenSearchRole{Description: fmt.Sprintf("Migrated from the ACLs of %s", acl.Username)}
+		var converted []OpenSearchACLRule
+		for _, rule := range acl.Rules {
+			actions, ok := openSearchACLActionGroups[rule.Permission]
+			if !ok {
+				msg := fmt.Sprintf("user %s: %s permission on %s is not converted", acl.Username, rule.Permission, rule.Index)
+				if rule.Permission == OpenSearchACLPermissionDeny && opts.SkipDenyRules {
+					warnings = append(warnings, msg)
+				} else {
+					problems = append(problems, msg)
+				}
+				continue
+			}
+
+			converted = append(converted, rule)
+			role.IndexPermissions = append(role.IndexPermissions, OpenSearchIndexPermission{
+				IndexPatterns:  []string{openSearchACLIndexPattern(rule.Index)},
+				AllowedActions: append([]string(nil), actions...),
+			})
+		}
+		problems = append(problems, openSearchACLEscalations(acl, converted)...)
+
+		if len(role.IndexPermissions) == 0 {
+			continue
+		}
+
+		name := "acl_" + acl.Username
+		result.Roles[name] = role
+		result.RoleMappings[name] = OpenSearchRoleMapping{
+			Description: role.Description,
+			Users:       []string{acl.Username},
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, warnings, fmt.Errorf("%w: %s", ErrOpenSearchACLNotConvertible, strings.Join(problems, "; "))
+	}
+
+	return result, warnings, nil
+}
@@ -0,0 +1,267 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenSearchSecurityConfigFromACLs(t *testing.T) {
+	conf := OpenSearchACLConfig{
+		Enabled: true,
+		ACLs: []OpenSearchACL{
+			{Username: "logger", Rules: []OpenSearchACLRule{
+				{Index: "logs-*", Permission: OpenSearchACLPermissionReadWrite},
+				{Index: "_all", Permission: OpenSearchACLPermissionRead},
+				{Index: "logs-secret", Permission: OpenSearchACLPermissionDeny},
+			}},
+			{Username: "blocked", Rules: []OpenSearchACLRule{{Index: "*", Permission: OpenSearchACLPermissionDeny}}},
+		},
+	}
+
+	// Deny rules cannot be expressed by the security plugin, dropping them would widen the access
+	_, _, err := OpenSearchSecurityConfigFromACLs(conf, nil)
+	assert.ErrorIs(t, err, ErrOpenSearchACLNotConvertible)
+	assert.ErrorContains(t, err, "user blocked: deny permission on *")
+
+	got, warnings, err := OpenSearchSecurityConfigFromACLs(conf, &OpenSearchACLConversionOptions{SkipDenyRules: true})
+	require.NoError(t, err)
+	assert.Equal(t, &OpenSearchSecurityConfig{
+		Roles: map[string]OpenSearchRole{
+			"acl_logger": {
+				Description: "Migrated from the ACLs of logger",
+				IndexPermissions: []OpenSearchIndexPermission{
+					{IndexPatterns: []string{"logs-*"}, AllowedActions: []string{"read", "write", "create_index"}},
+					{IndexPatterns: []string{"*"}, AllowedActions: []string{"read"}},
+				},
+			},
+		},
+		RoleMappings: map[string]OpenSearchRoleMapping{
+			"acl_logger": {Description: "Migrated from the ACLs of logger", Users: []string{"logger"}},
+		},
+	}, got)
+	assert.Equal(t, []string{
+		"user logger: deny permission on logs-secret is not converted",
+		"user blocked: deny permission on * is not converted",
+	}, warnings)
+}
+
+func TestOpenSearchSecurityConfigFromACLs_overlappingRules(t *testing.T) {
+	conf := OpenSearchACLConfig{
+		Enabled: true,
+		ACLs: []OpenSearchACL{
+			{Username: "ops", Rules: []OpenSearchACLRule{
+				{Index: "*", Permission: OpenSearchACLPermissionAdmin},
+				{Index: "logs", Permission: OpenSearchACLPermissionRead},
+			}},
+			{Username: "dev", Rules: []OpenSearchACLRule{
+				{Index: "metrics-*", Permission: OpenSearchACLPermissionAdmin},
+				{Index: "logs-*", Permission: OpenSearchACLPermissionRead},
+				{Index: "logs-*", Permission: OpenSearchACLPermissionWrite},
+			}},
+		},
+	}
+
+	// The ACLs limit ops to read on logs, the security plugin would grant the union, admin
+	_, _, err := OpenSearchSecurityConfigFromACLs(conf, nil)
+	assert.ErrorIs(t, err, ErrOpenSearchACLNotConvertible)
+	assert.ErrorContains(t, err, "user ops: admin permission on * is limited to read on logs")
+	assert.ErrorContains(t, err, "user dev: write permission on logs-* is limited to read on logs-*")
+	assert.NotContains(t, err.Error(), "metrics-*")
+}
+
+func Test_openSearchPatternsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"*", "logs", true},
+		{"_all", "logs-*", true},
+		{"logs-*", "*-2024", true},
+		{"logs-*", "metrics-*", false},
+		{"logs-?", "logs-ab", false},
+		{"logs-?", "logs-*", true},
+		{"logs", "logs", true},
+		{"logs", "logs2", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, openSearchPatternsOverlap(tt.a, tt.b), "%s and %s", tt.a, tt.b)
+		assert.Equal(t, tt.want, openSearchPatternsOverlap(tt.b, tt.a), "%s and %s", tt.b, tt.a)
+	}
+}
+
+func setupOpenSearchSecurityConfigTestCase(t *testing.T) (*Client, *[]string, func(t *testing.T)) {
+	t.Log("setup OpenSearch Security Config test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+		apiPath      = "/project/test-pr/service/test-sr/opensearch/proxy/_plugins/_security/api/"
+	)
+
+	state := map[string]map[string]json.RawMessage{
+		"internalusers": {
+			"admin": json.RawMessage(`{"hash": "x", "reserved": true}`),
+			"alice": json.RawMessage(`{"hash": "y", "backend_roles": ["dev"]}`),
+			"bob":   json.RawMessage(`{"hash": "z"}`),
+		},
+		"roles": {
+			"all_access": json.RawMessage(`{"reserved": true, "cluster_permissions": ["*"]}`),
+			"readers":    json.RawMessage(`{"index_permissions": [{"index_patterns": ["logs-*"], "allowed_actions": ["read"]}]}`),
+		},
+		"rolesmapping": {
+			"readers": json.RawMessage(`{"users": ["bob"]}`),
+		},
+	}
+
+	var changes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/userauth" {
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(authResponse{Token: AccessToken, State: "active"})
+			return
+		}
+
+		if !strings.HasPrefix(r.URL.Path, apiPath) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			return
+		}
+
+		kind, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, apiPath), "/")
+		entries, ok := state[kind]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			if name == "" {
+				w.WriteHeader(http.StatusOK)
+				_ = json.NewEncoder(w).Encode(entries)
+				return
+			}
+			e, ok := entries[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"status": "NOT_FOUND", "message": "not found"}`))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(map[string]json.RawMessage{name: e})
+			return
+		case http.MethodPut:
+			var body json.RawMessage
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			entries[name] = body
+			changes = append(changes, "PUT "+kind+"/"+name+" "+string(body))
+		case http.MethodDelete:
+			delete(entries, name)
+			changes = append(changes, "DELETE "+kind+"/"+name)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"status": "OK", "message": "done"}`))
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	return c, &changes, func(t *testing.T) {
+		t.Log("teardown OpenSearch Security Config test case")
+		ts.Close()
+	}
+}
+
+func TestOpenSearchSecurityHandlers(t *testing.T) {
+	c, changes, tearDown := setupOpenSearchSecurityConfigTestCase(t)
+	defer tearDown(t)
+
+	ctx := context.Background()
+
+	u, err := c.OpenSearchInternalUsers.Get(ctx, "test-pr", "test-sr", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev"}, u.BackendRoles)
+
+	_, err = c.OpenSearchRoles.Get(ctx, "test-pr", "test-sr", "writers")
+	assert.True(t, IsNotFound(err))
+
+	roles, err := c.OpenSearchRoles.List(ctx, "test-pr", "test-sr")
+	require.NoError(t, err)
+	assert.True(t, roles["all_access"].Reserved)
+
+	require.NoError(t, c.OpenSearchRoleMappings.Put(ctx, "test-pr", "test-sr", "readers", OpenSearchRoleMapping{Users: []string{"bob"}, Reserved: true}))
+	assert.Equal(t, []string{`PUT rolesmapping/readers {"users":["bob"]}`}, *changes)
+}
+
+func TestOpenSearchSecurityPluginHandler_SyncSecurityConfig(t *testing.T) {
+	c, changes, tearDown := setupOpenSearchSecurityConfigTestCase(t)
+	defer tearDown(t)
+
+	desired := OpenSearchSecurityConfig{
+		InternalUsers: map[string]OpenSearchInternalUser{
+			"alice": {Password: "ignored-unless-changed", BackendRoles: []string{"dev"}},
+			"carol": {Password: "secret"},
+		},
+		Roles: map[string]OpenSearchRole{
+			"readers": {IndexPermissions: []OpenSearchIndexPermission{{IndexPatterns: []string{"logs-*", "metrics-*"}, AllowedActions: []string{"read"}}}},
+			"writers": {IndexPermissions: []OpenSearchIndexPermission{{IndexPatterns: []string{"logs-*"}, AllowedActions: []string{"write"}}}},
+		},
+		RoleMappings: map[string]OpenSearchRoleMapping{
+			"readers": {Users: []string{"bob"}},
+			"writers": {Users: []string{"carol"}},
+		},
+	}
+
+	ctx := context.Background()
+	got, err := c.OpenSearchSecurityPluginHandler.SyncSecurityConfig(ctx, "test-pr", "test-sr", desired)
+	require.NoError(t, err)
+	assert.Equal(t, &OpenSearchSecuritySyncResult{
+		Created: []string{"roles/writers", "internalusers/carol", "rolesmapping/writers"},
+		Updated: []string{"roles/readers"},
+		Deleted: []string{"internalusers/bob"},
+	}, got)
+	assert.Contains(t, *changes, `PUT internalusers/carol {"password":"secret"}`)
+
+	*changes = nil
+	got, err = c.OpenSearchSecurityPluginHandler.SyncSecurityConfig(ctx, "test-pr", "test-sr", desired)
+	require.NoError(t, err)
+	assert.Equal(t, &OpenSearchSecuritySyncResult{}, got)
+	assert.Empty(t, *changes)
+}
+
+func TestOpenSearchSecurityPluginHandler_SyncSecurityConfigFromACLs(t *testing.T) {
+	c, changes, tearDown := setupOpenSearchSecurityConfigTestCase(t)
+	defer tearDown(t)
+
+	desired, _, err := OpenSearchSecurityConfigFromACLs(OpenSearchACLConfig{
+		Enabled: true,
+		ACLs: []OpenSearchACL{
+			{Username: "alice", Rules: []OpenSearchACLRule{{Index: "logs-*", Permission: OpenSearchACLPermissionRead}}},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	// The internal users are not managed by the converted config, so alice and bob are kept
+	got, err := c.OpenSearchSecurityPluginHandler.SyncSecurityConfig(context.Background(), "test-pr", "test-sr", *desired)
+	require.NoError(t, err)
+	assert.Equal(t, &OpenSearchSecuritySyncResult{
+		Created: []string{"roles/acl_alice", "rolesmapping/acl_alice"},
+		Deleted: []string{"rolesmapping/readers", "roles/readers"},
+	}, got)
+	for _, change := range *changes {
+		assert.NotContains(t, change, "internalusers")
+	}
+}
@@ -0,0 +1,296 @@
+package aiven
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// ApplicationUserTokenFlagUnused flags tokens not used for the policy UnusedDays.
+	ApplicationUserTokenFlagUnused = "unused"
+	// ApplicationUserTokenFlagExpiring flags tokens expiring within the policy ExpiresWithin.
+	ApplicationUserTokenFlagExpiring = "expiring"
+)
+
+type (
+	// ApplicationUserTokenSecretStore receives the replacement tokens created on rotation,
+	// e.g. a Kubernetes secret, a vault path or a CI variable.
+	ApplicationUserTokenSecretStore interface {
+		PutToken(ctx context.Context, orgID, userID, tokenPrefix, fullToken string) error
+	}
+
+	// ApplicationUserTokenPolicy decides which application user tokens are flagged.
+	ApplicationUserTokenPolicy struct {
+		// UnusedDays flags tokens not used for this many days, tokens never used count from their creation.
+		// Disabled when zero.
+		UnusedDays int
+		// ExpiresWithin flags tokens expiring within this duration, disabled when zero.
+		ExpiresWithin time.Duration
+	}
+
+	// ApplicationUserTokenReview is a flagged application user token.
+	ApplicationUserTokenReview struct {
+		UserID string
+		Token  ApplicationUserTokenInfo
+		Flags  []string
+	}
+
+	// ApplicationUserTokenRotation is a token replaced by a new one, the old token is deleted
+	// once the grace period is over.
+	ApplicationUserTokenRotation struct {
+		UserID      string    `json:"user_id"`
+		OldPrefix   string    `json:"old_prefix"`
+		NewPrefix   string    `json:"new_prefix"`
+		RotateTime  time.Time `json:"rotate_time"`
+		DeleteAfter time.Time `json:"delete_after"`
+	}
+
+	// ApplicationUserTokenManager flags, rotates and retires the tokens of the application users of an organization.
+	// The pending rotations are only held in memory, persist Pending after Rotate and DeleteRetired
+	// and Restore them on start so that the old tokens are still retired after a restart.
+	ApplicationUserTokenManager struct {
+		Handler        *OrganizationApplicationUserHandler
+		OrganizationID string
+		Policy         ApplicationUserTokenPolicy
+		Store          ApplicationUserTokenSecretStore
+		// GracePeriod is the time the old token remains valid after rotation.
+		GracePeriod time.Duration
+
+		mu      sync.Mutex
+		pending []ApplicationUserTokenRotation
+		// now is replaced in tests, defaults to time.Now.
+		now func() time.Time
+	}
+)
+
+// NewApplicationUserTokenManager creates an ApplicationUserTokenManager for the given organization.
+func NewApplicationUserTokenManager(
+	h *OrganizationApplicationUserHandler,
+	orgID string,
+	store ApplicationUserTokenSecretStore,
+	policy ApplicationUserTokenPolicy,
+	gracePeriod time.Duration,
+) *ApplicationUserTokenManager {
+	return &ApplicationUserTokenManager{
+		Handler:        h,
+		OrganizationID: orgID,
+		Policy:         policy,
+		Store:          store,
+		GracePeriod:    gracePeriod,
+		now:            time.Now,
+	}
+}
+
+// clock returns the current time, so that a manager built without NewApplicationUserTokenManager works too.
+func (m *ApplicationUserTokenManager) clock() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+
+	return m.now()
+}
+
+// Flags returns the policy flags of the token at the given time.
+func (p ApplicationUserTokenPolicy) Flags(t ApplicationUserTokenInfo, now time.Time) []string {
+	var flags []string
+
+	lastUsed := t.LastUsedTime
+	if lastUsed == nil {
+		lastUsed = t.CreateTime
+	}
+	if p.UnusedDays > 0 && lastUsed != nil && now.Sub(*lastUsed) > time.Duration(p.UnusedDays)*24*time.Hour {
+		flags = append(flags, ApplicationUserTokenFlagUnused)
+	}
+
+	if p.ExpiresWithin > 0 && t.ExpiryTime != nil && t.ExpiryTime.Sub(now) < p.ExpiresWithin {
+		flags = append(flags, ApplicationUserTokenFlagExpiring)
+	}
+
+	return flags
+}
+
+// Review returns the flagged tokens of every application user.
+// Tokens of a pending rotation are not flagged again.
+func (m *ApplicationUserTokenManager) Review(ctx context.Context) ([]ApplicationUserTokenReview, error) {
+	users, err := m.Handler.List(ctx, m.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated := make(map[string]bool)
+	for _, r := range m.Pending() {
+		rotated[r.OldPrefix] = true
+	}
+
+	now := m.clock()
+	var reviews []ApplicationUserTokenReview
+	for _, u := range users.Users {
+		tokens, err := m.Handler.ListTokens(ctx, m.OrganizationID, u.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tokens.Tokens {
+			if rotated[t.TokenPrefix] {
+				continue
+			}
+
+			if flags := m.Policy.Flags(t, now); len(flags) > 0 {
+				reviews = append(reviews, ApplicationUserTokenReview{UserID: u.UserID, Token: t, Flags: flags})
+			}
+		}
+	}
+
+	return reviews, nil
+}
+
+// Rotate creates a replacement token with the same description, scopes, maximum age and extension
+// and pushes it to the secret store. The old token is deleted by DeleteRetired after the grace period.
+// When the store fails the replacement token is deleted and the old one is kept.
+func (m *ApplicationUserTokenManager) Rotate(ctx context.Context, userID, tokenPrefix string) (*ApplicationUserTokenRotation, error) {
+	tokens, err := m.Handler.ListTokens(ctx, m.OrganizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var old *ApplicationUserTokenInfo
+	for i := range tokens.Tokens {
+		if tokens.Tokens[i].TokenPrefix == tokenPrefix {
+			old = &tokens.Tokens[i]
+			break
+		}
+	}
+
+	if old == nil {
+		return nil, Error{Message: fmt.Sprintf("application user token not found by prefix:%s", tokenPrefix), Status: 404}
+	}
+
+	req := ApplicationUserTokenCreateRequest{
+		Description:    old.Description,
+		ExtendWhenUsed: old.ExtendWhenUsed,
+		Scopes:         old.Scopes,
+	}
+	if old.MaxAgeSeconds > 0 {
+		req.MaxAgeSeconds = &old.MaxAgeSeconds
+	}
+
+	token, err := m.Handler.CreateToken(ctx, m.OrganizationID, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Store.PutToken(ctx, m.OrganizationID, userID, token.TokenPrefix, token.FullToken); err != nil {
+		if errD := m.Handler.DeleteToken(ctx, m.OrganizationID, userID, token.TokenPrefix); errD != nil {
+			return nil, fmt.Errorf("storing token: %w, deleting replacement token %s: %s", err, token.TokenPrefix, errD)
+		}
+		return nil, fmt.Errorf("storing token: %w", err)
+	}
+
+	now := m.clock()
+	r := ApplicationUserTokenRotation{
+		UserID:      userID,
+		OldPrefix:   tokenPrefix,
+		NewPrefix:   token.TokenPrefix,
+		RotateTime:  now,
+		DeleteAfter: now.Add(m.GracePeriod),
+	}
+
+	m.mu.Lock()
+	m.pending = append(m.pending, r)
+	m.mu.Unlock()
+
+	return &r, nil
+}
+
+// RotateFlagged rotates every token flagged by Review.
+func (m *ApplicationUserTokenManager) RotateFlagged(ctx context.Context) ([]ApplicationUserTokenRotation, error) {
+	reviews, err := m.Review(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rotations []ApplicationUserTokenRotation
+	for _, r := range reviews {
+		rotation, err := m.Rotate(ctx, r.UserID, r.Token.TokenPrefix)
+		if err != nil {
+			return rotations, err
+		}
+		rotations = append(rotations, *rotation)
+	}
+
+	return rotations, nil
+}
+
+// DeleteRetired deletes the old tokens whose grace period is over and returns their rotations.
+// The rotations of the tokens which could not be deleted are kept pending.
+func (m *ApplicationUserTokenManager) DeleteRetired(ctx context.Context) ([]ApplicationUserTokenRotation, error) {
+	now := m.clock()
+
+	var due []ApplicationUserTokenRotation
+	for _, r := range m.Pending() {
+		if !now.Before(r.DeleteAfter) {
+			due = append(due, r)
+		}
+	}
+
+	var (
+		deleted []ApplicationUserTokenRotation
+		err     error
+	)
+	for _, r := range due {
+		errD := m.Handler.DeleteToken(ctx, m.OrganizationID, r.UserID, r.OldPrefix)
+		if errD != nil && !IsNotFound(errD) {
+			err = errD
+			break
+		}
+		deleted = append(deleted, r)
+	}
+
+	retired := make(map[[2]string]bool, len(deleted))
+	for _, r := range deleted {
+		retired[[2]string{r.UserID, r.OldPrefix}] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.pending[:0]
+	for _, r := range m.pending {
+		if !retired[[2]string{r.UserID, r.OldPrefix}] {
+			kept = append(kept, r)
+		}
+	}
+	m.pending = kept
+
+	return deleted, err
+}
+
+// Restore adds previously persisted rotations to the pending ones, skipping those already pending.
+func (m *ApplicationUserTokenManager) Restore(rotations []ApplicationUserTokenRotation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range rotations {
+		found := false
+		for _, p := range m.pending {
+			if p.UserID == r.UserID && p.OldPrefix == r.OldPrefix {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			m.pending = append(m.pending, r)
+		}
+	}
+}
+
+// Pending returns the rotations whose old token is not deleted yet.
+func (m *ApplicationUserTokenManager) Pending() []ApplicationUserTokenRotation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]ApplicationUserTokenRotation(nil), m.pending...)
+}
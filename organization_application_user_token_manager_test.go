@@ -0,0 +1,210 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTokenStore struct {
+	tokens map[string]string
+	err    error
+}
+
+func (s *testTokenStore) PutToken(_ context.Context, _, userID, _, fullToken string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.tokens[userID] = fullToken
+	return nil
+}
+
+func TestApplicationUserTokenPolicy_Flags(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) *time.Time {
+		t := now.AddDate(0, 0, -d)
+		return &t
+	}
+
+	policy := ApplicationUserTokenPolicy{UnusedDays: 30, ExpiresWithin: 7 * 24 * time.Hour}
+	tests := []struct {
+		name  string
+		token ApplicationUserTokenInfo
+		want  []string
+	}{
+		{
+			name:  "recently used",
+			token: ApplicationUserTokenInfo{CreateTime: daysAgo(100), LastUsedTime: daysAgo(1)},
+		},
+		{
+			name:  "unused",
+			token: ApplicationUserTokenInfo{CreateTime: daysAgo(100), LastUsedTime: daysAgo(31)},
+			want:  []string{ApplicationUserTokenFlagUnused},
+		},
+		{
+			name:  "never used",
+			token: ApplicationUserTokenInfo{CreateTime: daysAgo(31)},
+			want:  []string{ApplicationUserTokenFlagUnused},
+		},
+		{
+			name:  "expiring and unused",
+			token: ApplicationUserTokenInfo{CreateTime: daysAgo(40), ExpiryTime: daysAgo(-3)},
+			want:  []string{ApplicationUserTokenFlagUnused, ApplicationUserTokenFlagExpiring},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Flags(tt.token, now))
+		})
+	}
+}
+
+func setupApplicationUserTokenManagerTestCase(t *testing.T) (*Client, *[]string, func(t *testing.T)) {
+	t.Log("setup Application User Token Manager test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+	)
+
+	var writes []string
+	lastUsed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		var rsp interface{}
+		switch {
+		case r.URL.Path == "/userauth":
+			rsp = authResponse{Token: AccessToken, State: "active"}
+		case r.URL.Path == "/organization/org-1/application-users":
+			rsp = ApplicationUserListResponse{Users: []ApplicationUserInfo{{UserID: "u-1"}}}
+		case r.URL.Path == "/organization/org-1/application-users/u-1/access-tokens" && r.Method == http.MethodGet:
+			rsp = ApplicationUserTokenListResponse{ApplicationUserTokenList: ApplicationUserTokenList{Tokens: []ApplicationUserTokenInfo{
+				{TokenPrefix: "old", LastUsedTime: &lastUsed, MaxAgeSeconds: 3600, Scopes: &[]string{"projects"}},
+			}}}
+		case r.URL.Path == "/organization/org-1/application-users/u-1/access-tokens":
+			var req map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+			b, _ := json.Marshal(req)
+			writes = append(writes, "create "+string(b))
+			rsp = ApplicationUserTokenCreateResponse{TokenPrefix: "new", FullToken: "new-full-token"}
+		case r.Method == http.MethodDelete:
+			writes = append(writes, "delete "+r.URL.Path)
+			rsp = struct{}{}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		if err := json.NewEncoder(w).Encode(rsp); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	return c, &writes, func(t *testing.T) {
+		t.Log("teardown Application User Token Manager test case")
+		ts.Close()
+	}
+}
+
+func TestApplicationUserTokenManager_RotateFlagged(t *testing.T) {
+	c, writes, tearDown := setupApplicationUserTokenManagerTestCase(t)
+	defer tearDown(t)
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := &testTokenStore{tokens: make(map[string]string)}
+	m := NewApplicationUserTokenManager(c.OrganizationApplicationUserHandler, "org-1", store, ApplicationUserTokenPolicy{UnusedDays: 30}, time.Hour)
+	m.now = func() time.Time { return now }
+
+	rotations, err := m.RotateFlagged(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rotations, 1)
+	assert.Equal(t, "old", rotations[0].OldPrefix)
+	assert.Equal(t, "new", rotations[0].NewPrefix)
+	assert.Equal(t, map[string]string{"u-1": "new-full-token"}, store.tokens)
+	assert.Equal(t, []string{`create {"max_age_seconds":3600,"scopes":["projects"]}`}, *writes)
+
+	// The rotated token is not flagged again
+	reviews, err := m.Review(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, reviews)
+
+	deleted, err := m.DeleteRetired(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	now = now.Add(2 * time.Hour)
+	deleted, err = m.DeleteRetired(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, deleted, 1)
+	assert.Empty(t, m.Pending())
+	assert.Equal(t, "delete /organization/org-1/application-users/u-1/access-tokens/old", (*writes)[1])
+}
+
+func TestApplicationUserTokenManager_Rotate_storeError(t *testing.T) {
+	c, writes, tearDown := setupApplicationUserTokenManagerTestCase(t)
+	defer tearDown(t)
+
+	// A manager built without the constructor uses the current time
+	storeErr := errors.New("store unavailable")
+	m := &ApplicationUserTokenManager{
+		Handler:        c.OrganizationApplicationUserHandler,
+		OrganizationID: "org-1",
+		Store:          &testTokenStore{err: storeErr},
+		GracePeriod:    time.Hour,
+	}
+
+	_, err := m.Rotate(context.Background(), "u-1", "old")
+	assert.ErrorIs(t, err, storeErr)
+	assert.Empty(t, m.Pending())
+	assert.Equal(t, "delete /organization/org-1/application-users/u-1/access-tokens/new", (*writes)[1])
+}
+
+func TestApplicationUserTokenManager_Restore(t *testing.T) {
+	c, writes, tearDown := setupApplicationUserTokenManagerTestCase(t)
+	defer tearDown(t)
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	m := NewApplicationUserTokenManager(c.OrganizationApplicationUserHandler, "org-1", &testTokenStore{}, ApplicationUserTokenPolicy{}, time.Hour)
+	m.now = func() time.Time { return now }
+
+	// The pending rotations survive a restart through JSON
+	bts, err := json.Marshal([]ApplicationUserTokenRotation{
+		{UserID: "u-1", OldPrefix: "old", NewPrefix: "new", RotateTime: now.Add(-2 * time.Hour), DeleteAfter: now.Add(-time.Hour)},
+		{UserID: "u-1", OldPrefix: "recent", NewPrefix: "newer", RotateTime: now, DeleteAfter: now.Add(time.Hour)},
+	})
+	require.NoError(t, err)
+
+	var persisted []ApplicationUserTokenRotation
+	require.NoError(t, json.Unmarshal(bts, &persisted))
+	m.Restore(persisted)
+	m.Restore(persisted[:1])
+	require.Len(t, m.Pending(), 2)
+
+	deleted, err := m.DeleteRetired(context.Background())
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, "old", deleted[0].OldPrefix)
+	assert.Equal(t, []string{"delete /organization/org-1/application-users/u-1/access-tokens/old"}, *writes)
+
+	pending := m.Pending()
+	require.Len(t, pending, 1)
+	assert.Equal(t, "recent", pending[0].OldPrefix)
+}
@@ -29,6 +29,8 @@ type (
 		UserID string `json:"user_id"`
 		// JoinTime is the time when the user joined the organization.
 		JoinTime *time.Time `json:"join_time"`
+		// LastActivityTime is the time of the last activity of the user.
+		LastActivityTime *time.Time `json:"last_activity_time"`
 		// UserInfo is the information of the user.
 		UserInfo OrganizationUserInfo `json:"user_info"`
 	}
@@ -0,0 +1,135 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// The clouds of the privatelink handlers, as used in the privatelink API paths.
+const (
+	PrivatelinkCloudAWS    = "aws"
+	PrivatelinkCloudAzure  = "azure"
+	PrivatelinkCloudGoogle = "google"
+)
+
+// PrivatelinkState is the cloud agnostic state of a privatelink.
+type PrivatelinkState string
+
+const (
+	// PrivatelinkStateCreating is the state of a privatelink being set up.
+	PrivatelinkStateCreating PrivatelinkState = "creating"
+	// PrivatelinkStateActive is the state of a privatelink accepting connections.
+	PrivatelinkStateActive PrivatelinkState = "active"
+	// PrivatelinkStateDeleting is the state of a privatelink being removed.
+	PrivatelinkStateDeleting PrivatelinkState = "deleting"
+	// PrivatelinkStateUnknown is the state of a privatelink reporting a state this client does not know.
+	PrivatelinkStateUnknown PrivatelinkState = "unknown"
+)
+
+// PrivatelinkConnectionState is the cloud agnostic state of a privatelink connection.
+type PrivatelinkConnectionState string
+
+const (
+	// PrivatelinkConnectionStatePendingApproval is the state of a connection waiting to be approved.
+	PrivatelinkConnectionStatePendingApproval PrivatelinkConnectionState = "pending-approval"
+	// PrivatelinkConnectionStateApproved is the state of an approved connection which is not active yet.
+	PrivatelinkConnectionStateApproved PrivatelinkConnectionState = "approved"
+	// PrivatelinkConnectionStateActive is the state of a connection carrying traffic.
+	PrivatelinkConnectionStateActive PrivatelinkConnectionState = "active"
+	// PrivatelinkConnectionStateClosed is the state of a rejected, failed or removed connection.
+	PrivatelinkConnectionStateClosed PrivatelinkConnectionState = "closed"
+	// PrivatelinkConnectionStateUnknown is the state of a connection reporting a state this client does not know.
+	PrivatelinkConnectionStateUnknown PrivatelinkConnectionState = "unknown"
+)
+
+var (
+	// ErrPrivatelinkNotSupported is returned when an operation is not available for the cloud.
+	ErrPrivatelinkNotSupported = errors.New("operation is not supported by the privatelink cloud")
+	// ErrPrivatelinkUserIPAddressRequired is returned when approving a connection which needs a user IP address without one.
+	ErrPrivatelinkUserIPAddressRequired = errors.New("privatelink connection approval requires a user IP address")
+)
+
+type (
+	// Privatelink is the cloud agnostic interface implemented by the AWS, Azure and GCP privatelink handlers,
+	// see Client.Privatelink. The privatelink is read with Info, Get keeps returning the cloud specific response.
+	Privatelink interface {
+		Cloud() string
+		Enable(ctx context.Context, project, serviceName string, opts PrivatelinkOptions) (*PrivatelinkInfo, error)
+		Info(ctx context.Context, project, serviceName string) (*PrivatelinkInfo, error)
+		Disable(ctx context.Context, project, serviceName string) error
+		ListConnections(ctx context.Context, project, serviceName string) ([]PrivatelinkConnection, error)
+		ApproveConnection(ctx context.Context, project, serviceName, connectionID, userIPAddress string) error
+		Refresh(ctx context.Context, project, serviceName string) error
+	}
+
+	// PrivatelinkOptions holds the cloud specific parameters to enable a privatelink.
+	PrivatelinkOptions struct {
+		// AWSPrincipals are the ARNs allowed to connect, AWS only.
+		AWSPrincipals []string
+		// AzureSubscriptionIDs are the subscriptions allowed to connect, Azure only.
+		AzureSubscriptionIDs []string
+	}
+
+	// PrivatelinkInfo represents a privatelink of any cloud.
+	PrivatelinkInfo struct {
+		Cloud    string
+		State    PrivatelinkState
+		RawState string
+		// ServiceID is the AWS service ID, the Azure service ID or the Google service attachment.
+		ServiceID string
+		// ServiceAlias is the AWS service name or the Azure service alias.
+		ServiceAlias string
+		// AllowedIDs are the AWS principals or the Azure subscription IDs.
+		AllowedIDs []string
+	}
+
+	// PrivatelinkConnection represents a privatelink connection of any cloud.
+	PrivatelinkConnection struct {
+		ID       string
+		State    PrivatelinkConnectionState
+		RawState string
+		// EndpointID is the AWS VPC endpoint ID, the Azure private endpoint ID or the GCP PSC connection ID.
+		EndpointID    string
+		UserIPAddress string
+		DNSName       string
+	}
+)
+
+// Privatelink returns the privatelink interface of the given cloud: aws, azure or google.
+func (c *Client) Privatelink(cloud string) (Privatelink, error) {
+	switch cloud {
+	case PrivatelinkCloudAWS:
+		return c.AWSPrivatelink, nil
+	case PrivatelinkCloudAzure:
+		return c.AzurePrivatelink, nil
+	case PrivatelinkCloudGoogle:
+		return c.GCPPrivatelink, nil
+	}
+
+	return nil, fmt.Errorf("unknown privatelink cloud %q", cloud)
+}
+
+func newPrivatelinkState(state string) PrivatelinkState {
+	switch s := PrivatelinkState(state); s {
+	case PrivatelinkStateCreating, PrivatelinkStateActive, PrivatelinkStateDeleting:
+		return s
+	}
+
+	return PrivatelinkStateUnknown
+}
+
+func newPrivatelinkConnectionState(state string) PrivatelinkConnectionState {
+	switch state {
+	case "pending-user-approval", "pending-acceptance", "pendingAcceptance":
+		return PrivatelinkConnectionStatePendingApproval
+	case "user-approved", "pending":
+		return PrivatelinkConnectionStateApproved
+	case "connected", "active", "available":
+		return PrivatelinkConnectionStateActive
+	case "rejected", "deleting", "deleted", "disconnected", "failed", "expired":
+		return PrivatelinkConnectionStateClosed
+	}
+
+	return PrivatelinkConnectionStateUnknown
+}
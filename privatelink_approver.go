@@ -0,0 +1,237 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// PrivatelinkApprovalPolicy decides which pending privatelink connections are approved.
+	// A connection is approved when it matches any of the allowed lists or the Match function.
+	PrivatelinkApprovalPolicy struct {
+		// AllowedAccountIDs are the Azure subscription IDs found in the private endpoint ID, Azure only.
+		// GCP connections only carry the numeric PSC connection ID, so reconciling a GCP privatelink
+		// with allowed account IDs fails with ErrPrivatelinkNotSupported.
+		AllowedAccountIDs []string
+		// AllowedEndpointIDs are the exact endpoint IDs allowed to connect.
+		AllowedEndpointIDs []string
+		// Match is an optional custom matcher.
+		Match func(conn PrivatelinkConnection) bool
+		// UserIPAddress returns the IP address assigned to the connection endpoint on approval, optional.
+		// The user IP address the connection already reports is used when it is nil or returns an empty string.
+		UserIPAddress func(conn PrivatelinkConnection) string
+	}
+
+	// PrivatelinkApprovalDecision records what the approver did with a pending connection.
+	PrivatelinkApprovalDecision struct {
+		Time         time.Time
+		ConnectionID string
+		EndpointID   string
+		Approved     bool
+		Reason       string
+		Err          error
+	}
+
+	// PrivatelinkApprover periodically refreshes a privatelink and approves
+	// the pending connections allowed by its policy.
+	PrivatelinkApprover struct {
+		Privatelink Privatelink
+		Project     string
+		ServiceName string
+		Policy      PrivatelinkApprovalPolicy
+		// Interval defaults to DefaultPollInterval.
+		Interval time.Duration
+		// OnDecision is called for every decision, optional.
+		OnDecision func(d PrivatelinkApprovalDecision)
+		// OnError is called when a reconciliation pass fails, optional.
+		OnError func(err error)
+
+		mu sync.Mutex
+		// decisions holds the latest decision of every connection still listed, in the order they were first seen.
+		decisions map[string]PrivatelinkApprovalDecision
+		order     []string
+		rejected  map[string]bool
+	}
+)
+
+// NewPrivatelinkApprover creates a PrivatelinkApprover for the given service privatelink.
+func NewPrivatelinkApprover(p Privatelink, project, serviceName string, policy PrivatelinkApprovalPolicy) *PrivatelinkApprover {
+	return &PrivatelinkApprover{
+		Privatelink: p,
+		Project:     project,
+		ServiceName: serviceName,
+		Policy:      policy,
+	}
+}
+
+// privatelinkAccountID extracts the Azure subscription from a private endpoint ID.
+func privatelinkAccountID(endpointID string) string {
+	parts := strings.Split(strings.Trim(endpointID, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if strings.EqualFold(parts[i], "subscriptions") {
+			return parts[i+1]
+		}
+	}
+
+	return ""
+}
+
+// Allows reports whether the connection may be approved and why.
+func (p PrivatelinkApprovalPolicy) Allows(conn PrivatelinkConnection) (bool, string) {
+	for _, id := range p.AllowedEndpointIDs {
+		if strings.EqualFold(id, conn.EndpointID) {
+			return true, "endpoint ID is allowed"
+		}
+	}
+
+	if account := privatelinkAccountID(conn.EndpointID); account != "" {
+		for _, id := range p.AllowedAccountIDs {
+			if strings.EqualFold(id, account) {
+				return true, "account " + account + " is allowed"
+			}
+		}
+	}
+
+	if p.Match != nil && p.Match(conn) {
+		return true, "matched by policy"
+	}
+
+	return false, "not allowed by policy"
+}
+
+// Decisions returns the latest decision of every connection the privatelink still lists.
+func (a *PrivatelinkApprover) Decisions() []PrivatelinkApprovalDecision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	decisions := make([]PrivatelinkApprovalDecision, 0, len(a.order))
+	for _, id := range a.order {
+		decisions = append(decisions, a.decisions[id])
+	}
+
+	return decisions
+}
+
+func (a *PrivatelinkApprover) record(d PrivatelinkApprovalDecision) {
+	a.mu.Lock()
+	if a.decisions == nil {
+		a.decisions = make(map[string]PrivatelinkApprovalDecision)
+	}
+	if _, ok := a.decisions[d.ConnectionID]; !ok {
+		a.order = append(a.order, d.ConnectionID)
+	}
+	a.decisions[d.ConnectionID] = d
+	if !d.Approved && d.Err == nil {
+		if a.rejected == nil {
+			a.rejected = make(map[string]bool)
+		}
+		a.rejected[d.ConnectionID] = true
+	}
+	a.mu.Unlock()
+
+	if a.OnDecision != nil {
+		a.OnDecision(d)
+	}
+}
+
+// forget drops the state of the connections which are no longer listed, so that it does not grow without bound.
+func (a *PrivatelinkApprover) forget(conns []PrivatelinkConnection) {
+	listed := make(map[string]bool, len(conns))
+	for _, conn := range conns {
+		listed[conn.ID] = true
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	order := a.order[:0]
+	for _, id := range a.order {
+		if listed[id] {
+			order = append(order, id)
+			continue
+		}
+		delete(a.decisions, id)
+	}
+	a.order = order
+
+	for id := range a.rejected {
+		if !listed[id] {
+			delete(a.rejected, id)
+		}
+	}
+}
+
+func (a *PrivatelinkApprover) isRejected(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.rejected[id]
+}
+
+// Reconcile runs a single pass: refreshes the privatelink, lists its connections
+// and approves the allowed pending ones. Rejected connections are only reported once,
+// failed approvals are retried on the next pass.
+func (a *PrivatelinkApprover) Reconcile(ctx context.Context) ([]PrivatelinkApprovalDecision, error) {
+	if cloud := a.Privatelink.Cloud(); len(a.Policy.AllowedAccountIDs) > 0 && cloud != PrivatelinkCloudAzure {
+		return nil, fmt.Errorf("%w: allowed account IDs on %s", ErrPrivatelinkNotSupported, cloud)
+	}
+
+	err := a.Privatelink.Refresh(ctx, a.Project, a.ServiceName)
+	if err != nil && !errors.Is(err, ErrPrivatelinkNotSupported) {
+		return nil, err
+	}
+
+	conns, err := a.Privatelink.ListConnections(ctx, a.Project, a.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	a.forget(conns)
+
+	var decisions []PrivatelinkApprovalDecision
+	for _, conn := range conns {
+		if conn.State != PrivatelinkConnectionStatePendingApproval || a.isRejected(conn.ID) {
+			continue
+		}
+
+		d := PrivatelinkApprovalDecision{
+			Time:         time.Now(),
+			ConnectionID: conn.ID,
+			EndpointID:   conn.EndpointID,
+		}
+		d.Approved, d.Reason = a.Policy.Allows(conn)
+
+		if d.Approved {
+			var ip string
+			if a.Policy.UserIPAddress != nil {
+				ip = a.Policy.UserIPAddress(conn)
+			}
+			if ip == "" {
+				ip = conn.UserIPAddress
+			}
+
+			if d.Err = a.Privatelink.ApproveConnection(ctx, a.Project, a.ServiceName, conn.ID, ip); d.Err != nil {
+				d.Approved = false
+			}
+		}
+
+		a.record(d)
+		decisions = append(decisions, d)
+	}
+
+	return decisions, nil
+}
+
+// Run reconciles every Interval until the context is done and returns its error.
+func (a *PrivatelinkApprover) Run(ctx context.Context) error {
+	return waitFor(ctx, a.Interval, func() (bool, error) {
+		if _, err := a.Reconcile(ctx); err != nil && ctx.Err() == nil && a.OnError != nil {
+			a.OnError(err)
+		}
+
+		return false, nil
+	})
+}
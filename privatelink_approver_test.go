@@ -0,0 +1,174 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testPrivatelink struct {
+	Privatelink
+
+	cloud    string
+	conns    []PrivatelinkConnection
+	approved map[string]string
+	fail     bool
+}
+
+func (p *testPrivatelink) Cloud() string {
+	if p.cloud == "" {
+		return PrivatelinkCloudAzure
+	}
+	return p.cloud
+}
+
+func (p *testPrivatelink) Refresh(context.Context, string, string) error {
+	return ErrPrivatelinkNotSupported
+}
+
+func (p *testPrivatelink) ListConnections(context.Context, string, string) ([]PrivatelinkConnection, error) {
+	return p.conns, nil
+}
+
+func (p *testPrivatelink) ApproveConnection(_ context.Context, _, _, id, ip string) error {
+	if p.fail {
+		return errors.New("approval failed")
+	}
+
+	p.approved[id] = ip
+	for i := range p.conns {
+		if p.conns[i].ID == id {
+			p.conns[i].State = PrivatelinkConnectionStateApproved
+		}
+	}
+	return nil
+}
+
+func TestPrivatelinkApprover_Reconcile(t *testing.T) {
+	p := &testPrivatelink{
+		approved: map[string]string{},
+		conns: []PrivatelinkConnection{
+			{ID: "plc1", State: PrivatelinkConnectionStatePendingApproval, EndpointID: "/subscriptions/sub-a/resourceGroups/rg/providers/Microsoft.Network/privateEndpoints/pe1"},
+			{ID: "plc2", State: PrivatelinkConnectionStatePendingApproval, EndpointID: "/subscriptions/sub-b/resourceGroups/rg/providers/Microsoft.Network/privateEndpoints/pe2"},
+			{ID: "plc3", State: PrivatelinkConnectionStatePendingApproval, EndpointID: "pe3"},
+			{ID: "plc4", State: PrivatelinkConnectionStateActive, EndpointID: "pe4"},
+		},
+	}
+
+	a := NewPrivatelinkApprover(p, "test-pr", "test-sr", PrivatelinkApprovalPolicy{
+		AllowedAccountIDs:  []string{"SUB-A"},
+		AllowedEndpointIDs: []string{"pe3", "pe4"},
+		UserIPAddress: func(conn PrivatelinkConnection) string {
+			return "10.0.0." + conn.ID[3:]
+		},
+	})
+
+	ctx := context.Background()
+	got, err := a.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if len(got) != 3 || !got[0].Approved || got[1].Approved || !got[2].Approved {
+		t.Errorf("Reconcile() got = %+v", got)
+	}
+
+	want := map[string]string{"plc1": "10.0.0.1", "plc3": "10.0.0.3"}
+	if len(p.approved) != len(want) || p.approved["plc1"] != want["plc1"] || p.approved["plc3"] != want["plc3"] {
+		t.Errorf("Reconcile() approved = %v, want %v", p.approved, want)
+	}
+
+	// Rejected connections are reported once
+	if got, _ := a.Reconcile(ctx); len(got) != 0 {
+		t.Errorf("Reconcile() second pass got = %+v", got)
+	}
+	if len(a.Decisions()) != 3 {
+		t.Errorf("Decisions() got = %+v", a.Decisions())
+	}
+}
+
+func TestPrivatelinkApprover_RunStopsOnCancel(t *testing.T) {
+	p := &testPrivatelink{
+		fail:  true,
+		conns: []PrivatelinkConnection{{ID: "plc1", State: PrivatelinkConnectionStatePendingApproval, EndpointID: "pe1"}},
+	}
+
+	a := NewPrivatelinkApprover(p, "test-pr", "test-sr", PrivatelinkApprovalPolicy{AllowedEndpointIDs: []string{"pe1"}})
+	a.Interval = time.Millisecond
+
+	var attempts int
+	a.OnDecision = func(PrivatelinkApprovalDecision) {
+		attempts++
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := a.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Failed approvals are retried on every pass, only the latest decision is kept
+	if attempts < 2 {
+		t.Errorf("Run() attempts = %d, want at least 2", attempts)
+	}
+	if d := a.Decisions(); len(d) != 1 || d[0].Err == nil {
+		t.Errorf("Decisions() got = %+v", d)
+	}
+}
+
+func TestPrivatelinkApprover_ForgetsRemovedConnections(t *testing.T) {
+	p := &testPrivatelink{
+		conns: []PrivatelinkConnection{
+			{ID: "plc1", State: PrivatelinkConnectionStatePendingApproval, EndpointID: "pe1"},
+			{ID: "plc2", State: PrivatelinkConnectionStatePendingApproval, EndpointID: "pe2"},
+		},
+	}
+
+	a := NewPrivatelinkApprover(p, "test-pr", "test-sr", PrivatelinkApprovalPolicy{})
+
+	ctx := context.Background()
+	if _, err := a.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if d := a.Decisions(); len(d) != 2 || d[0].ConnectionID != "plc1" || d[1].ConnectionID != "plc2" {
+		t.Errorf("Decisions() got = %+v", d)
+	}
+
+	p.conns = p.conns[1:]
+	if _, err := a.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if d := a.Decisions(); len(d) != 1 || d[0].ConnectionID != "plc2" {
+		t.Errorf("Decisions() got = %+v", d)
+	}
+	if a.isRejected("plc1") {
+		t.Error("isRejected() kept a removed connection")
+	}
+}
+
+func TestPrivatelinkApprover_Google(t *testing.T) {
+	p := &testPrivatelink{
+		cloud:    PrivatelinkCloudGoogle,
+		approved: map[string]string{},
+		conns: []PrivatelinkConnection{
+			{ID: "plc1", State: PrivatelinkConnectionStatePendingApproval, EndpointID: "1234567890", UserIPAddress: "10.0.0.1"},
+		},
+	}
+
+	ctx := context.Background()
+	a := NewPrivatelinkApprover(p, "test-pr", "test-sr", PrivatelinkApprovalPolicy{AllowedAccountIDs: []string{"gcp-project"}})
+	if _, err := a.Reconcile(ctx); !errors.Is(err, ErrPrivatelinkNotSupported) {
+		t.Fatalf("Reconcile() error = %v, want %v", err, ErrPrivatelinkNotSupported)
+	}
+
+	// The user IP address reported by the connection is used without a policy one
+	a = NewPrivatelinkApprover(p, "test-pr", "test-sr", PrivatelinkApprovalPolicy{AllowedEndpointIDs: []string{"1234567890"}})
+	if _, err := a.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if p.approved["plc1"] != "10.0.0.1" {
+		t.Errorf("Reconcile() approved = %v", p.approved)
+	}
+}
@@ -0,0 +1,234 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// privatelinkTestRequest is a request received by the privatelink test server.
+type privatelinkTestRequest struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+func setupPrivatelinkTestCase(t *testing.T) (*Client, func() []privatelinkTestRequest, func(t *testing.T)) {
+	t.Log("setup Privatelink test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+	)
+
+	var (
+		mu       sync.Mutex
+		requests []privatelinkTestRequest
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		var rsp interface{} = struct{}{}
+		switch r.URL.Path {
+		case "/userauth":
+			rsp = authResponse{Token: AccessToken, State: "active"}
+		case "/project/test-pr/service/test-sr/privatelink/aws/connections":
+			rsp = AWSPrivatelinkConnectionsResponse{Connections: []AWSPrivatelinkConnectionResponse{
+				{PrivatelinkConnectionID: "plc1", State: "pending-user-approval", VPCEndpointID: "vpce-1", DNSName: "vpce-1.example.com"},
+				{PrivatelinkConnectionID: "plc2", State: "active", VPCEndpointID: "vpce-2"},
+			}}
+		case "/project/test-pr/service/test-sr/privatelink/azure/connections":
+			rsp = AzurePrivatelinkConnectionsResponse{Connections: []AzurePrivatelinkConnectionResponse{
+				{PrivatelinkConnectionID: "plc1", State: "pending-user-approval", PrivateEndpointID: "pe1"},
+				{PrivatelinkConnectionID: "plc2", State: "user-approved", PrivateEndpointID: "pe2", UserIPAddress: "10.0.0.2"},
+			}}
+		case "/project/test-pr/service/test-sr/privatelink/google/connections":
+			rsp = GCPPrivatelinkConnectionsResponse{Connections: []GCPPrivatelinkConnectionResponse{
+				{PrivatelinkConnectionID: "plc1", State: "pending-user-approval", PSCConnectionID: "psc-1"},
+				{PrivatelinkConnectionID: "plc2", State: "rejected", PSCConnectionID: "psc-2"},
+			}}
+		default:
+			if r.Method == http.MethodGet {
+				break
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			mu.Lock()
+			requests = append(requests, privatelinkTestRequest{Method: r.Method, Path: r.URL.Path, Body: string(body)})
+			mu.Unlock()
+		}
+
+		if err := json.NewEncoder(w).Encode(rsp); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	received := func() []privatelinkTestRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]privatelinkTestRequest(nil), requests...)
+	}
+
+	return c, received, func(t *testing.T) {
+		t.Log("teardown Privatelink test case")
+		ts.Close()
+	}
+}
+
+func Test_newPrivatelinkConnectionState(t *testing.T) {
+	tests := map[string]PrivatelinkConnectionState{
+		"pending-user-approval": PrivatelinkConnectionStatePendingApproval,
+		"pending-acceptance":    PrivatelinkConnectionStatePendingApproval,
+		"pendingAcceptance":     PrivatelinkConnectionStatePendingApproval,
+		"user-approved":         PrivatelinkConnectionStateApproved,
+		"pending":               PrivatelinkConnectionStateApproved,
+		"connected":             PrivatelinkConnectionStateActive,
+		"active":                PrivatelinkConnectionStateActive,
+		"available":             PrivatelinkConnectionStateActive,
+		"rejected":              PrivatelinkConnectionStateClosed,
+		"deleting":              PrivatelinkConnectionStateClosed,
+		"deleted":               PrivatelinkConnectionStateClosed,
+		"disconnected":          PrivatelinkConnectionStateClosed,
+		"failed":                PrivatelinkConnectionStateClosed,
+		"expired":               PrivatelinkConnectionStateClosed,
+		"":                      PrivatelinkConnectionStateUnknown,
+		"something-new":         PrivatelinkConnectionStateUnknown,
+	}
+	for state, want := range tests {
+		assert.Equal(t, want, newPrivatelinkConnectionState(state), state)
+	}
+}
+
+func TestPrivatelink_ListConnections(t *testing.T) {
+	c, _, tearDown := setupPrivatelinkTestCase(t)
+	defer tearDown(t)
+
+	tests := []struct {
+		cloud string
+		want  []PrivatelinkConnection
+	}{
+		{
+			cloud: PrivatelinkCloudAWS,
+			want: []PrivatelinkConnection{
+				{ID: "plc1", State: PrivatelinkConnectionStatePendingApproval, RawState: "pending-user-approval", EndpointID: "vpce-1", DNSName: "vpce-1.example.com"},
+				{ID: "plc2", State: PrivatelinkConnectionStateActive, RawState: "active", EndpointID: "vpce-2"},
+			},
+		},
+		{
+			cloud: PrivatelinkCloudAzure,
+			want: []PrivatelinkConnection{
+				{ID: "plc1", State: PrivatelinkConnectionStatePendingApproval, RawState: "pending-user-approval", EndpointID: "pe1"},
+				{ID: "plc2", State: PrivatelinkConnectionStateApproved, RawState: "user-approved", EndpointID: "pe2", UserIPAddress: "10.0.0.2"},
+			},
+		},
+		{
+			cloud: PrivatelinkCloudGoogle,
+			want: []PrivatelinkConnection{
+				{ID: "plc1", State: PrivatelinkConnectionStatePendingApproval, RawState: "pending-user-approval", EndpointID: "psc-1"},
+				{ID: "plc2", State: PrivatelinkConnectionStateClosed, RawState: "rejected", EndpointID: "psc-2"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cloud, func(t *testing.T) {
+			p, err := c.Privatelink(tt.cloud)
+			require.NoError(t, err)
+			assert.Equal(t, tt.cloud, p.Cloud())
+
+			got, err := p.ListConnections(context.Background(), "test-pr", "test-sr")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := c.Privatelink("oracle")
+	assert.Error(t, err)
+}
+
+func TestPrivatelink_ApproveConnection(t *testing.T) {
+	tests := []struct {
+		name    string
+		cloud   string
+		ip      string
+		want    []privatelinkTestRequest
+		wantErr error
+	}{
+		{
+			name:    "aws",
+			cloud:   PrivatelinkCloudAWS,
+			ip:      "10.0.0.1",
+			wantErr: ErrPrivatelinkNotSupported,
+		},
+		{
+			name:    "azure without user ip address",
+			cloud:   PrivatelinkCloudAzure,
+			wantErr: ErrPrivatelinkUserIPAddressRequired,
+		},
+		{
+			name:  "azure",
+			cloud: PrivatelinkCloudAzure,
+			ip:    "10.0.0.1",
+			want: []privatelinkTestRequest{
+				{Method: http.MethodPut, Path: "/project/test-pr/service/test-sr/privatelink/azure/connections/plc1", Body: `{"user_ip_address":"10.0.0.1"}`},
+				{Method: http.MethodPost, Path: "/project/test-pr/service/test-sr/privatelink/azure/connections/plc1/approve"},
+			},
+		},
+		{
+			name:  "google",
+			cloud: PrivatelinkCloudGoogle,
+			ip:    "10.0.0.1",
+			want: []privatelinkTestRequest{
+				{Method: http.MethodPost, Path: "/project/test-pr/service/test-sr/privatelink/google/connections/plc1/approve", Body: `{"user_ip_address":"10.0.0.1"}`},
+			},
+		},
+		{
+			name:    "google without user ip address",
+			cloud:   PrivatelinkCloudGoogle,
+			wantErr: ErrPrivatelinkUserIPAddressRequired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, received, tearDown := setupPrivatelinkTestCase(t)
+			defer tearDown(t)
+
+			p, err := c.Privatelink(tt.cloud)
+			require.NoError(t, err)
+
+			err = p.ApproveConnection(context.Background(), "test-pr", "test-sr", "plc1", tt.ip)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "ApproveConnection() error = %v, want %v", err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			got := received()
+			for i := range got {
+				got[i].Body = strings.TrimSpace(got[i].Body)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
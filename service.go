@@ -5,6 +5,13 @@ import (
 	"time"
 )
 
+const (
+	ServiceStateRunning     = "RUNNING"
+	ServiceStateRebuilding  = "REBUILDING"
+	ServiceStateRebalancing = "REBALANCING"
+	ServiceStatePowerOff    = "POWEROFF"
+)
+
 type (
 	// Service represents the Service model on Aiven.
 	Service struct {
@@ -386,3 +393,44 @@ func (h *ServicesHandler) List(ctx context.Context, project string) ([]*Service,
 
 	return r.Services, errR
 }
+
+// UpdateUserConfig sets the given user config keys on top of the current user config of a service.
+// Update is a PUT, the fields which are not omitted when empty are carried over from the current service,
+// a service in the POWEROFF state stays powered off.
+func (h *ServicesHandler) UpdateUserConfig(ctx context.Context, project, service string, userConfig map[string]interface{}) (*Service, error) {
+	s, err := h.Get(ctx, project, service)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]interface{}, len(s.UserConfig)+len(userConfig))
+	for k, v := range s.UserConfig {
+		merged[k] = v
+	}
+	for k, v := range userConfig {
+		merged[k] = v
+	}
+
+	return h.Update(ctx, project, service, UpdateServiceRequest{
+		ProjectVPCID:          s.ProjectVPCID,
+		Powered:               s.State != ServiceStatePowerOff,
+		TerminationProtection: s.TerminationProtection,
+		UserConfig:            merged,
+	})
+}
+
+// WaitForState polls the service until it reaches the given state, e.g. RUNNING.
+func (h *ServicesHandler) WaitForState(ctx context.Context, project, service, state string, interval time.Duration) (*Service, error) {
+	var s *Service
+	err := waitFor(ctx, interval, func() (bool, error) {
+		var err error
+		s, err = h.Get(ctx, project, service)
+		if err != nil {
+			return false, err
+		}
+
+		return s.State == state, nil
+	})
+
+	return s, err
+}
@@ -0,0 +1,262 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// BackupRecoveryModePITR is the plan recovery mode allowing to restore any point in time.
+	BackupRecoveryModePITR = "pitr"
+
+	// maxServiceNameLength is the maximum length of a service name.
+	maxServiceNameLength = 63
+)
+
+var (
+	// ErrNoBackups is returned when a service has no backups to recover from.
+	ErrNoBackups = errors.New("service has no backups")
+
+	// ErrRecoveryTargetOutOfRange is returned when a recovery target is outside the recovery window.
+	ErrRecoveryTargetOutOfRange = errors.New("recovery target time is outside the recovery window")
+
+	// ErrPointInTimeRecoveryNotSupported is returned when a recovery target time is given for a plan
+	// without point-in-time recovery, which can only restore a backup by name.
+	ErrPointInTimeRecoveryNotSupported = errors.New("service plan has no point-in-time recovery")
+
+	// ErrBackupNotFound is returned when the backup to restore is not among the backups of the service.
+	ErrBackupNotFound = errors.New("backup not found")
+)
+
+type (
+	// BackupRecoveryWindow represents the points in time a service can be restored to.
+	// Without point-in-time recovery, only the times of the Backups can be restored.
+	BackupRecoveryWindow struct {
+		PointInTime bool
+		Earliest    time.Time
+		Latest      time.Time
+		Backups     []*Backup
+	}
+
+	// ServiceForkRequest are the parameters to create a new service from a backup of another one.
+	ServiceForkRequest struct {
+		// ServiceName defaults to "<source>-fork-<timestamp>".
+		ServiceName string
+		// Plan and Cloud default to the source service plan and cloud.
+		Plan  string
+		Cloud string
+		// BackupName restores the given base backup, PostgreSQL only.
+		BackupName string
+		// RecoveryTargetTime restores the given point in time, validated against the recovery window.
+		// It requires a plan with point-in-time recovery and cannot be combined with BackupName.
+		RecoveryTargetTime *time.Time
+		// UserConfig is merged on top of the fork user config.
+		UserConfig map[string]interface{}
+		// Wait blocks until the new service is RUNNING.
+		Wait         bool
+		PollInterval time.Duration
+	}
+)
+
+// backupRetention returns how far back backups are kept according to the plan backup config.
+func backupRetention(cfg *BackupConfig) time.Duration {
+	oldest := cfg.InfrequentOldestAgeMinutes
+	if cfg.FrequentOldestAgeMinutes > oldest {
+		oldest = cfg.FrequentOldestAgeMinutes
+	}
+	if oldest > 0 {
+		return time.Duration(oldest) * time.Minute
+	}
+
+	return time.Duration(cfg.Interval*cfg.MaxCount) * time.Hour
+}
+
+// NewBackupRecoveryWindow computes the recovery window from the plan backup config and the existing backups.
+// A nil config is treated as a plan without point-in-time recovery.
+func NewBackupRecoveryWindow(cfg *BackupConfig, backups []*Backup, now time.Time) (*BackupRecoveryWindow, error) {
+	w := &BackupRecoveryWindow{Latest: now}
+
+	for _, b := range backups {
+		if b.BackupTime == nil {
+			continue
+		}
+
+		w.Backups = append(w.Backups, b)
+		if w.Earliest.IsZero() || b.BackupTime.Before(w.Earliest) {
+			w.Earliest = *b.BackupTime
+		}
+	}
+
+	if len(w.Backups) == 0 {
+		return nil, ErrNoBackups
+	}
+
+	if cfg == nil || cfg.RecoveryMode != BackupRecoveryModePITR {
+		w.Latest = w.Earliest
+		for _, b := range w.Backups {
+			if b.BackupTime.After(w.Latest) {
+				w.Latest = *b.BackupTime
+			}
+		}
+		return w, nil
+	}
+
+	w.PointInTime = true
+	if retention := backupRetention(cfg); retention > 0 {
+		if start := now.Add(-retention); start.After(w.Earliest) {
+			w.Earliest = start
+		}
+	}
+
+	return w, nil
+}
+
+// Validate checks the target time can be recovered.
+func (w *BackupRecoveryWindow) Validate(target time.Time) error {
+	if !w.PointInTime {
+		return fmt.Errorf("%w: restore a backup by name instead of %s",
+			ErrPointInTimeRecoveryNotSupported, target.Format(time.RFC3339))
+	}
+
+	if target.Before(w.Earliest) || target.After(w.Latest) {
+		return fmt.Errorf("%w: %s not in [%s, %s]", ErrRecoveryTargetOutOfRange,
+			target.Format(time.RFC3339), w.Earliest.Format(time.RFC3339), w.Latest.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// Validate checks the backup or the point in time to restore against the recovery window of the source service.
+func (r ServiceForkRequest) Validate(w *BackupRecoveryWindow) error {
+	if r.BackupName != "" && r.RecoveryTargetTime != nil {
+		return errors.New("backup name and recovery target time are mutually exclusive")
+	}
+
+	if r.RecoveryTargetTime != nil {
+		return w.Validate(*r.RecoveryTargetTime)
+	}
+
+	if r.BackupName != "" {
+		for _, b := range w.Backups {
+			if b.BackupName == r.BackupName {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrBackupNotFound, r.BackupName)
+	}
+
+	return nil
+}
+
+// servicePlan looks up the plan of the service among the project service types.
+func (h *ServicesHandler) servicePlan(ctx context.Context, project string, s *Service) (*ServicePlan, error) {
+	types, err := h.client.Projects.ServiceTypes(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range types[s.Type].ServicePlans {
+		if p.ServicePlan == s.Plan {
+			return &p, nil
+		}
+	}
+
+	return nil, Error{Message: fmt.Sprintf("service plan %s not found for service type %s", s.Plan, s.Type), Status: 404}
+}
+
+// RecoveryWindow returns the recovery window of the service.
+func (h *ServicesHandler) RecoveryWindow(ctx context.Context, project, service string) (*BackupRecoveryWindow, error) {
+	s, err := h.Get(ctx, project, service)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := h.servicePlan(ctx, project, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBackupRecoveryWindow(plan.BackupConfig, s.Backups, time.Now())
+}
+
+// forkServiceName builds a fork name which fits the service name length limit.
+func forkServiceName(source string, now time.Time) string {
+	suffix := "-fork-" + now.UTC().Format("200601021504")
+	if len(source)+len(suffix) > maxServiceNameLength {
+		source = source[:maxServiceNameLength-len(suffix)]
+	}
+
+	return source + suffix
+}
+
+// Fork creates a new service from a backup or a point in time of the given service.
+func (h *ServicesHandler) Fork(ctx context.Context, project, service string, req ServiceForkRequest) (*Service, error) {
+	source, err := h.Get(ctx, project, service)
+	if err != nil {
+		return nil, err
+	}
+
+	userConfig := map[string]interface{}{
+		"project_to_fork_from": project,
+		"service_to_fork_from": service,
+	}
+
+	if req.RecoveryTargetTime != nil || req.BackupName != "" {
+		plan, err := h.servicePlan(ctx, project, source)
+		if err != nil {
+			return nil, err
+		}
+
+		w, err := NewBackupRecoveryWindow(plan.BackupConfig, source.Backups, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := req.Validate(w); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.RecoveryTargetTime != nil {
+		userConfig["recovery_target_time"] = req.RecoveryTargetTime.UTC().Format(time.RFC3339)
+	}
+
+	if req.BackupName != "" {
+		userConfig["recovery_basebackup_name"] = req.BackupName
+	}
+
+	for k, v := range req.UserConfig {
+		userConfig[k] = v
+	}
+
+	name := req.ServiceName
+	if name == "" {
+		name = forkServiceName(service, time.Now())
+	}
+
+	plan := req.Plan
+	if plan == "" {
+		plan = source.Plan
+	}
+
+	cloud := req.Cloud
+	if cloud == "" {
+		cloud = source.CloudName
+	}
+
+	s, err := h.Create(ctx, project, CreateServiceRequest{
+		Cloud:        cloud,
+		Plan:         plan,
+		ProjectVPCID: source.ProjectVPCID,
+		ServiceName:  name,
+		ServiceType:  source.Type,
+		UserConfig:   userConfig,
+	})
+	if err != nil || !req.Wait {
+		return s, err
+	}
+
+	return h.WaitForState(ctx, project, name, ServiceStateRunning, req.PollInterval)
+}
@@ -0,0 +1,138 @@
+package aiven
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackupRecoveryWindow(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	backups := []*Backup{
+		{BackupName: "old", BackupTime: ref(now.Add(-72 * time.Hour))},
+		{BackupName: "new", BackupTime: ref(now.Add(-24 * time.Hour))},
+		{BackupName: "pending"},
+	}
+
+	tests := []struct {
+		name         string
+		cfg          *BackupConfig
+		backups      []*Backup
+		wantEarliest time.Time
+		wantLatest   time.Time
+		target       time.Time
+		wantErr      error
+	}{
+		{
+			name:         "pitr limited by retention",
+			cfg:          &BackupConfig{RecoveryMode: "pitr", Interval: 24, MaxCount: 2},
+			backups:      backups,
+			wantEarliest: now.Add(-48 * time.Hour),
+			wantLatest:   now,
+			target:       now.Add(-time.Hour),
+		},
+		{
+			name:         "pitr target too old",
+			cfg:          &BackupConfig{RecoveryMode: "pitr", Interval: 24, MaxCount: 2},
+			backups:      backups,
+			wantEarliest: now.Add(-48 * time.Hour),
+			wantLatest:   now,
+			target:       now.Add(-60 * time.Hour),
+			wantErr:      ErrRecoveryTargetOutOfRange,
+		},
+		{
+			name:         "basic backup time",
+			cfg:          &BackupConfig{RecoveryMode: "basic"},
+			backups:      backups,
+			wantEarliest: now.Add(-72 * time.Hour),
+			wantLatest:   now.Add(-24 * time.Hour),
+			target:       now.Add(-24 * time.Hour),
+			wantErr:      ErrPointInTimeRecoveryNotSupported,
+		},
+		{
+			name:         "basic between backups",
+			backups:      backups,
+			wantEarliest: now.Add(-72 * time.Hour),
+			wantLatest:   now.Add(-24 * time.Hour),
+			target:       now.Add(-30 * time.Hour),
+			wantErr:      ErrPointInTimeRecoveryNotSupported,
+		},
+		{
+			name:    "no backups",
+			cfg:     &BackupConfig{RecoveryMode: "pitr"},
+			wantErr: ErrNoBackups,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewBackupRecoveryWindow(tt.cfg, tt.backups, now)
+			if err != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("NewBackupRecoveryWindow() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if !w.Earliest.Equal(tt.wantEarliest) || !w.Latest.Equal(tt.wantLatest) {
+				t.Errorf("NewBackupRecoveryWindow() got = [%s, %s], want [%s, %s]", w.Earliest, w.Latest, tt.wantEarliest, tt.wantLatest)
+			}
+
+			if err := w.Validate(tt.target); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServiceForkRequest_Validate(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	backups := []*Backup{{BackupName: "new", BackupTime: ref(now.Add(-24 * time.Hour))}}
+	target := now.Add(-time.Hour)
+
+	pitr, err := NewBackupRecoveryWindow(&BackupConfig{RecoveryMode: "pitr"}, backups, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	basic, err := NewBackupRecoveryWindow(nil, backups, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		req     ServiceForkRequest
+		w       *BackupRecoveryWindow
+		wantErr error
+	}{
+		{name: "backup name", req: ServiceForkRequest{BackupName: "new"}, w: basic},
+		{name: "unknown backup name", req: ServiceForkRequest{BackupName: "old"}, w: pitr, wantErr: ErrBackupNotFound},
+		{name: "recovery target time", req: ServiceForkRequest{RecoveryTargetTime: &target}, w: pitr},
+		{name: "recovery target time without pitr", req: ServiceForkRequest{RecoveryTargetTime: &target}, w: basic, wantErr: ErrPointInTimeRecoveryNotSupported},
+		{name: "latest state", w: basic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(tt.w); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	both := ServiceForkRequest{BackupName: "new", RecoveryTargetTime: &target}
+	if err := both.Validate(pitr); err == nil {
+		t.Errorf("Validate() accepted both a backup name and a recovery target time")
+	}
+}
+
+func TestForkServiceName(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 30, 0, 0, time.UTC)
+
+	if got := forkServiceName("pg", now); got != "pg-fork-202405101230" {
+		t.Errorf("forkServiceName() got = %v", got)
+	}
+
+	long := "a-very-long-service-name-which-does-not-leave-room-for-suffix"
+	if got := forkServiceName(long, now); len(got) != maxServiceNameLength {
+		t.Errorf("forkServiceName() got = %v, len %d", got, len(got))
+	}
+}
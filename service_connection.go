@@ -0,0 +1,394 @@
+package aiven
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ServiceComponentRouteDynamic is the default route of a service component.
+	ServiceComponentRouteDynamic = "dynamic"
+	// ServiceComponentRoutePublic is the public route of a service component in a VPC.
+	ServiceComponentRoutePublic = "public"
+	// ServiceComponentRoutePrivate is the private route of a service component in a VPC.
+	ServiceComponentRoutePrivate = "private"
+	// ServiceComponentRoutePrivatelink is the route of a service component through a privatelink.
+	ServiceComponentRoutePrivatelink = "privatelink"
+
+	// ServiceComponentUsagePrimary marks the component pointing to the primary node.
+	ServiceComponentUsagePrimary = "primary"
+	// ServiceComponentUsageReplica marks the component pointing to the read replicas.
+	ServiceComponentUsageReplica = "replica"
+
+	// KafkaAuthenticationMethodCertificate is the Kafka client certificate authentication method.
+	KafkaAuthenticationMethodCertificate = "certificate"
+	// KafkaAuthenticationMethodSASL is the Kafka SASL/SCRAM authentication method.
+	KafkaAuthenticationMethodSASL = "sasl"
+
+	// MySQLTLSConfigName is the TLS config name used in generated MySQL DSNs.
+	MySQLTLSConfigName = "aiven"
+)
+
+var (
+	// ErrServiceComponentNotFound is returned when no service component matches the requested route and usage.
+	ErrServiceComponentNotFound = errors.New("service component not found")
+)
+
+type (
+	// ServiceConnectionOptions selects the service component used to build connection artifacts.
+	ServiceConnectionOptions struct {
+		// Route is one of dynamic, public, private or privatelink. Defaults to dynamic.
+		Route string
+		// Usage is either primary or replica. Defaults to primary.
+		Usage string
+		// KafkaAuthenticationMethod is either certificate or sasl. Defaults to certificate.
+		KafkaAuthenticationMethod string
+	}
+
+	// ServiceConnection holds everything needed to connect to a Service as a given ServiceUser.
+	ServiceConnection struct {
+		Service       *Service
+		User          *ServiceUser
+		CACertificate string
+		Options       ServiceConnectionOptions
+	}
+
+	// KafkaClientConfig represents Kafka client properties with inlined PEM material.
+	KafkaClientConfig struct {
+		Properties map[string]string
+	}
+
+	// ClickhouseConnectionURLs represents the native, HTTPS and MySQL interface ClickHouse URLs.
+	ClickhouseConnectionURLs struct {
+		Native string
+		HTTPS  string
+		MySQL  string
+	}
+)
+
+// Component returns the first service component with the given name, route and usage.
+// Empty route and usage default to dynamic and primary.
+func (s *Service) Component(component, route, usage string) (*ServiceComponents, error) {
+	if route == "" {
+		route = ServiceComponentRouteDynamic
+	}
+	if usage == "" {
+		usage = ServiceComponentUsagePrimary
+	}
+
+	for _, c := range s.Components {
+		if c.Component == component && c.Route == route && c.Usage == usage {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s (route %s, usage %s)", ErrServiceComponentNotFound, component, route, usage)
+}
+
+// NewServiceConnection creates a ServiceConnection, the caCertificate is the project CA
+// as returned by CAHandler.Get.
+func NewServiceConnection(service *Service, user *ServiceUser, caCertificate string, opts ServiceConnectionOptions) *ServiceConnection {
+	return &ServiceConnection{
+		Service:       service,
+		User:          user,
+		CACertificate: caCertificate,
+		Options:       opts,
+	}
+}
+
+// Connection retrieves the service, the given service user and the project CA
+// and returns a ServiceConnection to generate connection artifacts from.
+func (h *ServicesHandler) Connection(ctx context.Context, project, service, username string, opts ServiceConnectionOptions) (*ServiceConnection, error) {
+	s, err := h.Get(ctx, project, service)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *ServiceUser
+	for _, u := range s.Users {
+		if u.Username == username {
+			user = u
+			break
+		}
+	}
+
+	if user == nil {
+		return nil, Error{Message: fmt.Sprintf("Service user with username %v not found", username), Status: 404}
+	}
+
+	ca, err := h.client.CA.Get(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewServiceConnection(s, user, ca, opts), nil
+}
+
+func (c *ServiceConnection) component(name string) (*ServiceComponents, error) {
+	return c.Service.Component(name, c.Options.Route, c.Options.Usage)
+}
+
+func (c *ServiceConnection) address(name string) (*ServiceComponents, string, error) {
+	comp, err := c.component(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return comp, net.JoinHostPort(comp.Host, strconv.Itoa(comp.Port)), nil
+}
+
+// isSSL reports whether the component requires TLS, services default to TLS when unknown.
+func isSSL(comp *ServiceComponents) bool {
+	return comp.Ssl == nil || *comp.Ssl
+}
+
+// TLSConfig returns a TLS configuration trusting the project CA.
+func (c *ServiceConnection) TLSConfig() (*tls.Config, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(c.CACertificate)) {
+		return nil, errors.New("cannot parse project CA certificate")
+	}
+
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// PostgresDSN returns a libpq keyword/value connection string.
+func (c *ServiceConnection) PostgresDSN(database string) (string, error) {
+	comp, err := c.component("pg")
+	if err != nil {
+		return "", err
+	}
+
+	sslMode := "disable"
+	if isSSL(comp) {
+		sslMode = "require"
+	}
+
+	params := [][2]string{
+		{"host", comp.Host},
+		{"port", strconv.Itoa(comp.Port)},
+		{"user", c.User.Username},
+		{"password", c.User.Password},
+		{"dbname", database},
+		{"sslmode", sslMode},
+	}
+
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		parts = append(parts, p[0]+"="+quoteLibpqValue(p[1]))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// quoteLibpqValue quotes a libpq connection string value when needed.
+func quoteLibpqValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, " '\\") {
+		return v
+	}
+
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// PostgresURI returns a postgres:// URI usable by libpq and pgx.
+func (c *ServiceConnection) PostgresURI(database string) (string, error) {
+	comp, addr, err := c.address("pg")
+	if err != nil {
+		return "", err
+	}
+
+	sslMode := "disable"
+	if isSSL(comp) {
+		sslMode = "require"
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User.Username, c.User.Password),
+		Host:     addr,
+		Path:     "/" + database,
+		RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+	}
+
+	return u.String(), nil
+}
+
+// PgPass returns a .pgpass line for the given database.
+func (c *ServiceConnection) PgPass(database string) (string, error) {
+	comp, err := c.component("pg")
+	if err != nil {
+		return "", err
+	}
+
+	fields := []string{comp.Host, strconv.Itoa(comp.Port), database, c.User.Username, c.User.Password}
+	for i, f := range fields {
+		f = strings.ReplaceAll(f, `\`, `\\`)
+		fields[i] = strings.ReplaceAll(f, `:`, `\:`)
+	}
+
+	return strings.Join(fields, ":") + "\n", nil
+}
+
+// MySQLDSN returns a DSN in the go-sql-driver/mysql format.
+// When TLS is required, the DSN refers to MySQLTLSConfigName,
+// register TLSConfig under that name before opening the connection.
+func (c *ServiceConnection) MySQLDSN(database string) (string, error) {
+	comp, addr, err := c.address("mysql")
+	if err != nil {
+		return "", err
+	}
+
+	tlsParam := "false"
+	if isSSL(comp) {
+		tlsParam = MySQLTLSConfigName
+	}
+
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?tls=%s", c.User.Username, c.User.Password, addr, database, tlsParam), nil
+}
+
+// KafkaClientConfig returns the Kafka client properties for the selected authentication method.
+// PEM material is inlined, so the properties can be used without keystore files.
+func (c *ServiceConnection) KafkaClientConfig() (*KafkaClientConfig, error) {
+	method := c.Options.KafkaAuthenticationMethod
+	if method == "" {
+		method = KafkaAuthenticationMethodCertificate
+	}
+
+	var comp *ServiceComponents
+	for _, k := range c.Service.Components {
+		route := c.Options.Route
+		if route == "" {
+			route = ServiceComponentRouteDynamic
+		}
+
+		if k.Component == "kafka" && k.Route == route && k.KafkaAuthenticationMethod == method {
+			comp = k
+			break
+		}
+	}
+
+	if comp == nil {
+		return nil, fmt.Errorf("%w: kafka (authentication method %s)", ErrServiceComponentNotFound, method)
+	}
+
+	props := map[string]string{
+		"bootstrap.servers":           net.JoinHostPort(comp.Host, strconv.Itoa(comp.Port)),
+		"ssl.truststore.type":         "PEM",
+		"ssl.truststore.certificates": c.CACertificate,
+	}
+
+	switch method {
+	case KafkaAuthenticationMethodCertificate:
+		if c.User.AccessCert == "" || c.User.AccessKey == "" {
+			return nil, fmt.Errorf("service user %s has no access certificate", c.User.Username)
+		}
+
+		props["security.protocol"] = "SSL"
+		props["ssl.keystore.type"] = "PEM"
+		props["ssl.keystore.certificate.chain"] = c.User.AccessCert
+		props["ssl.keystore.key"] = c.User.AccessKey
+	case KafkaAuthenticationMethodSASL:
+		props["security.protocol"] = "SASL_SSL"
+		props["sasl.mechanism"] = "SCRAM-SHA-256"
+		props["sasl.jaas.config"] = fmt.Sprintf(
+			`org.apache.kafka.common.security.scram.ScramLoginModule required username=%q password=%q;`,
+			c.User.Username,
+			c.User.Password,
+		)
+	default:
+		return nil, fmt.Errorf("unsupported kafka authentication method %q", method)
+	}
+
+	return &KafkaClientConfig{Properties: props}, nil
+}
+
+// String renders the properties in the Java properties format, sorted by key.
+func (k *KafkaClientConfig) String() string {
+	keys := make([]string, 0, len(k.Properties))
+	for key := range k.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		v := strings.ReplaceAll(k.Properties[key], `\`, `\\`)
+		v = strings.ReplaceAll(v, "\n", `\n`)
+		b.WriteString(key + "=" + v + "\n")
+	}
+
+	return b.String()
+}
+
+// ValkeyURL returns a redis:// or rediss:// URL for Valkey, Redis and Dragonfly services.
+func (c *ServiceConnection) ValkeyURL() (string, error) {
+	comp, addr, err := c.address(c.Service.Type)
+	if err != nil {
+		return "", err
+	}
+
+	scheme := "redis"
+	if isSSL(comp) {
+		scheme = "rediss"
+	}
+
+	u := url.URL{Scheme: scheme, User: url.UserPassword(c.User.Username, c.User.Password), Host: addr}
+	return u.String(), nil
+}
+
+// OpenSearchURL returns the OpenSearch URL with credentials.
+func (c *ServiceConnection) OpenSearchURL() (string, error) {
+	return c.httpURL("opensearch")
+}
+
+// ClickhouseURLs returns the native, HTTPS and MySQL interface URLs of a ClickHouse service,
+// missing interfaces are left empty.
+func (c *ServiceConnection) ClickhouseURLs() (*ClickhouseConnectionURLs, error) {
+	var r ClickhouseConnectionURLs
+
+	comp, addr, err := c.address("clickhouse")
+	if err != nil {
+		return nil, err
+	}
+
+	native := url.URL{Scheme: "clickhouse", User: url.UserPassword(c.User.Username, c.User.Password), Host: addr}
+	if isSSL(comp) {
+		native.RawQuery = url.Values{"secure": {"true"}}.Encode()
+	}
+	r.Native = native.String()
+
+	if r.HTTPS, err = c.httpURL("clickhouse_https"); err != nil && !errors.Is(err, ErrServiceComponentNotFound) {
+		return nil, err
+	}
+
+	if _, addr, err := c.address("clickhouse_mysql"); err == nil {
+		r.MySQL = fmt.Sprintf("%s:%s@tcp(%s)/?tls=%s", c.User.Username, c.User.Password, addr, MySQLTLSConfigName)
+	}
+
+	return &r, nil
+}
+
+func (c *ServiceConnection) httpURL(component string) (string, error) {
+	comp, addr, err := c.address(component)
+	if err != nil {
+		return "", err
+	}
+
+	scheme := "http"
+	if isSSL(comp) {
+		scheme = "https"
+	}
+
+	u := url.URL{Scheme: scheme, User: url.UserPassword(c.User.Username, c.User.Password), Host: addr}
+	return u.String(), nil
+}
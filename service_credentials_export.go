@@ -0,0 +1,222 @@
+package aiven
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type (
+	// ServiceCredentialsKeys are the key names used when exporting service credentials.
+	// User keys are prefixed with the normalised username when more than one user is exported.
+	ServiceCredentialsKeys struct {
+		Host          string
+		Port          string
+		CACertificate string
+		Username      string
+		Password      string
+		AccessCert    string
+		AccessKey     string
+	}
+
+	// ServiceCredentialsExportOptions configures the exported secret.
+	ServiceCredentialsExportOptions struct {
+		// Name is the Kubernetes Secret name, defaults to the service name.
+		Name string
+		// Namespace is the Kubernetes Secret namespace, omitted when empty.
+		Namespace string
+		// Labels are added to the Kubernetes Secret metadata.
+		Labels map[string]string
+		// Keys overrides the default key names, empty fields fall back to the defaults.
+		Keys ServiceCredentialsKeys
+	}
+
+	// ServiceCredentialsExport holds the flattened credentials of a service
+	// and renders them in a deterministic order.
+	ServiceCredentialsExport struct {
+		Name      string
+		Namespace string
+		Labels    map[string]string
+		Data      map[string]string
+	}
+)
+
+// DefaultServiceCredentialsKeys returns the default key names.
+func DefaultServiceCredentialsKeys() ServiceCredentialsKeys {
+	return ServiceCredentialsKeys{
+		Host:          "HOST",
+		Port:          "PORT",
+		CACertificate: "CA_CERT",
+		Username:      "USERNAME",
+		Password:      "PASSWORD",
+		AccessCert:    "ACCESS_CERT",
+		AccessKey:     "ACCESS_KEY",
+	}
+}
+
+func (k ServiceCredentialsKeys) withDefaults() ServiceCredentialsKeys {
+	d := DefaultServiceCredentialsKeys()
+	for _, f := range []struct{ v, def *string }{
+		{&k.Host, &d.Host},
+		{&k.Port, &d.Port},
+		{&k.CACertificate, &d.CACertificate},
+		{&k.Username, &d.Username},
+		{&k.Password, &d.Password},
+		{&k.AccessCert, &d.AccessCert},
+		{&k.AccessKey, &d.AccessKey},
+	} {
+		if *f.v == "" {
+			*f.v = *f.def
+		}
+	}
+
+	return k
+}
+
+// NewServiceCredentialsExport flattens the service, the given users and the project CA into a ServiceCredentialsExport.
+func NewServiceCredentialsExport(service *Service, users []*ServiceUser, caCertificate string, opts ServiceCredentialsExportOptions) *ServiceCredentialsExport {
+	keys := opts.Keys.withDefaults()
+	data := make(map[string]string)
+
+	set := func(key, value string) {
+		if value != "" {
+			data[key] = value
+		}
+	}
+
+	set(keys.Host, service.URIParams["host"])
+	set(keys.Port, service.URIParams["port"])
+	set(keys.CACertificate, caCertificate)
+
+	for _, u := range users {
+		prefix := ""
+		if len(users) > 1 {
+			prefix = credentialsKeyPrefix(u.Username)
+		}
+
+		set(prefix+keys.Username, u.Username)
+		set(prefix+keys.Password, u.Password)
+		set(prefix+keys.AccessCert, u.AccessCert)
+		set(prefix+keys.AccessKey, u.AccessKey)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = service.Name
+	}
+
+	return &ServiceCredentialsExport{
+		Name:      name,
+		Namespace: opts.Namespace,
+		Labels:    opts.Labels,
+		Data:      data,
+	}
+}
+
+// credentialsKeyPrefix turns a username into an environment variable friendly key prefix.
+func credentialsKeyPrefix(username string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(username) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+
+	return b.String() + "_"
+}
+
+// ExportCredentials retrieves the service, the given service users and the project CA
+// and returns them as a ServiceCredentialsExport.
+func (h *ServicesHandler) ExportCredentials(ctx context.Context, project, service string, usernames []string, opts ServiceCredentialsExportOptions) (*ServiceCredentialsExport, error) {
+	s, err := h.Get(ctx, project, service)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*ServiceUser, 0, len(usernames))
+	for _, name := range usernames {
+		var user *ServiceUser
+		for _, u := range s.Users {
+			if u.Username == name {
+				user = u
+				break
+			}
+		}
+
+		if user == nil {
+			return nil, Error{Message: fmt.Sprintf("Service user with username %v not found", name), Status: 404}
+		}
+		users = append(users, user)
+	}
+
+	ca, err := h.client.CA.Get(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewServiceCredentialsExport(s, users, ca, opts), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// yamlString quotes a string for YAML, a JSON string is a valid YAML double-quoted scalar.
+func yamlString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// KubernetesSecret renders the credentials as a Kubernetes Secret manifest.
+func (e *ServiceCredentialsExport) KubernetesSecret() []byte {
+	var b bytes.Buffer
+	b.WriteString("apiVersion: v1\n")
+	b.WriteString("kind: Secret\n")
+	b.WriteString("metadata:\n")
+	b.WriteString("  name: " + yamlString(e.Name) + "\n")
+	if e.Namespace != "" {
+		b.WriteString("  namespace: " + yamlString(e.Namespace) + "\n")
+	}
+	if len(e.Labels) > 0 {
+		b.WriteString("  labels:\n")
+		for _, k := range sortedKeys(e.Labels) {
+			b.WriteString("    " + yamlString(k) + ": " + yamlString(e.Labels[k]) + "\n")
+		}
+	}
+	b.WriteString("type: Opaque\n")
+	b.WriteString("data:\n")
+	for _, k := range sortedKeys(e.Data) {
+		b.WriteString("  " + yamlString(k) + ": " + base64.StdEncoding.EncodeToString([]byte(e.Data[k])) + "\n")
+	}
+
+	return b.Bytes()
+}
+
+// DotEnv renders the credentials as a dotenv file, multiline values are escaped.
+func (e *ServiceCredentialsExport) DotEnv() []byte {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`)
+
+	var b bytes.Buffer
+	for _, k := range sortedKeys(e.Data) {
+		b.WriteString(k + `="` + r.Replace(e.Data[k]) + "\"\n")
+	}
+
+	return b.Bytes()
+}
+
+// JSON renders the credentials as a JSON object with sorted keys.
+func (e *ServiceCredentialsExport) JSON() ([]byte, error) {
+	return json.MarshalIndent(e.Data, "", "  ")
+}
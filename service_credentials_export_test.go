@@ -0,0 +1,67 @@
+package aiven
+
+import (
+	"testing"
+)
+
+func TestServiceCredentialsExport(t *testing.T) {
+	service := &Service{
+		Name:      "test-sr",
+		URIParams: map[string]string{"host": "pg.aivencloud.com", "port": "5432"},
+	}
+	users := []*ServiceUser{
+		{Username: "avnadmin", Password: "a\"b"},
+		{Username: "app-user", Password: "c$d"},
+	}
+
+	e := NewServiceCredentialsExport(service, users, "CA\nCA", ServiceCredentialsExportOptions{
+		Namespace: "default",
+		Labels:    map[string]string{"team": "data", "app": "api"},
+		Keys:      ServiceCredentialsKeys{CACertificate: "ca.pem"},
+	})
+
+	wantSecret := `apiVersion: v1
+kind: Secret
+metadata:
+  name: "test-sr"
+  namespace: "default"
+  labels:
+    "app": "api"
+    "team": "data"
+type: Opaque
+data:
+  "APP_USER_PASSWORD": YyRk
+  "APP_USER_USERNAME": YXBwLXVzZXI=
+  "AVNADMIN_PASSWORD": YSJi
+  "AVNADMIN_USERNAME": YXZuYWRtaW4=
+  "HOST": cGcuYWl2ZW5jbG91ZC5jb20=
+  "PORT": NTQzMg==
+  "ca.pem": Q0EKQ0E=
+`
+	if got := string(e.KubernetesSecret()); got != wantSecret {
+		t.Errorf("KubernetesSecret() got = %v, want %v", got, wantSecret)
+	}
+
+	wantEnv := `APP_USER_PASSWORD="c\$d"
+APP_USER_USERNAME="app-user"
+AVNADMIN_PASSWORD="a\"b"
+AVNADMIN_USERNAME="avnadmin"
+HOST="pg.aivencloud.com"
+PORT="5432"
+ca.pem="CA\nCA"
+`
+	if got := string(e.DotEnv()); got != wantEnv {
+		t.Errorf("DotEnv() got = %v, want %v", got, wantEnv)
+	}
+
+	first, err := e.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	second, _ := NewServiceCredentialsExport(service, users, "CA\nCA", ServiceCredentialsExportOptions{
+		Keys: ServiceCredentialsKeys{CACertificate: "ca.pem"},
+	}).JSON()
+	if string(first) != string(second) {
+		t.Errorf("JSON() is not deterministic: %s != %s", first, second)
+	}
+}
@@ -0,0 +1,164 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	MigrationMethodDump        = "dump"
+	MigrationMethodReplication = "replication"
+
+	MigrationStatusRunning = "running"
+	MigrationStatusSyncing = "syncing"
+	MigrationStatusDone    = "done"
+	MigrationStatusFailed  = "failed"
+)
+
+// ErrMigrationFailed is returned when a migration from an external database reaches the failed status.
+var ErrMigrationFailed = errors.New("migration failed")
+
+type (
+	// MigrationCheckRequest are the parameters of a migration_check service task.
+	MigrationCheckRequest struct {
+		SourceServiceURI  string `json:"source_service_uri,omitempty"`
+		SourceProjectName string `json:"source_project_name,omitempty"`
+		SourceServiceName string `json:"source_service_name,omitempty"`
+		// IgnoreDBs is a comma-separated list of databases to skip.
+		IgnoreDBs string `json:"ignore_dbs,omitempty"`
+		// IgnoreRoles is a comma-separated list of roles to skip, PostgreSQL only.
+		IgnoreRoles string `json:"ignore_roles,omitempty"`
+		// Method is either dump or replication, the service picks one when empty.
+		Method string `json:"method,omitempty"`
+	}
+
+	// MigrationStatusResponse Aiven API response
+	// GET https://api.aiven.io/v1/project/<project>/service/<service_name>/migration
+	MigrationStatusResponse struct {
+		APIResponse
+		Migration       MigrationStatus           `json:"migration"`
+		MigrationDetail []MigrationDatabaseStatus `json:"migration_detail"`
+	}
+
+	// MigrationStatus represents the overall status of a migration from an external database
+	MigrationStatus struct {
+		Error                  string   `json:"error"`
+		Method                 string   `json:"method"`
+		Status                 string   `json:"status"`
+		SourceActive           *bool    `json:"source_active"`
+		MasterLastIOSecondsAgo *float64 `json:"master_last_io_seconds_ago"`
+		MasterLinkStatus       string   `json:"master_link_status"`
+	}
+
+	// MigrationDatabaseStatus represents the migration status of a single database
+	MigrationDatabaseStatus struct {
+		DatabaseName string `json:"dbname"`
+		Error        string `json:"error"`
+		Method       string `json:"method"`
+		Status       string `json:"status"`
+	}
+
+	// MigrationProgress is a snapshot of a running migration suitable for a progress bar.
+	MigrationProgress struct {
+		Status    *MigrationStatusResponse
+		Completed int
+		Total     int
+		// Done is set once the migration reached a final state, replication migrations
+		// are done once every database is syncing.
+		Done bool
+		// Err is set when polling fails or, wrapping ErrMigrationFailed, when the migration failed.
+		Err error
+	}
+)
+
+// MigrationCheck runs a migration_check task against the source database and waits for its result.
+func (h ServiceTaskHandler) MigrationCheck(ctx context.Context, project, service string, req MigrationCheckRequest, interval time.Duration) (*ServiceTaskCheckResult, error) {
+	return h.RunCheck(ctx, project, service, ServiceTaskRequest{
+		TaskType:       ServiceTaskTypeMigrationCheck,
+		MigrationCheck: &req,
+	}, interval)
+}
+
+// MigrationStatus retrieves the status of the migration of the service from an external database.
+func (h ServiceTaskHandler) MigrationStatus(ctx context.Context, project, service string) (*MigrationStatusResponse, error) {
+	path := buildPath("project", project, "service", service, "migration")
+	bts, err := h.client.doGetRequest(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rsp MigrationStatusResponse
+	if errR := checkAPIResponse(bts, &rsp); errR != nil {
+		return nil, errR
+	}
+
+	return &rsp, nil
+}
+
+// newMigrationProgress counts the databases which finished their initial copy.
+func newMigrationProgress(s *MigrationStatusResponse) MigrationProgress {
+	p := MigrationProgress{Status: s, Total: len(s.MigrationDetail)}
+	for _, d := range s.MigrationDetail {
+		if d.Status == MigrationStatusDone || d.Status == MigrationStatusSyncing {
+			p.Completed++
+		}
+	}
+
+	switch s.Migration.Status {
+	case MigrationStatusDone:
+		p.Done = true
+	case MigrationStatusFailed:
+		p.Done = true
+		p.Err = ErrMigrationFailed
+		if s.Migration.Error != "" {
+			p.Err = fmt.Errorf("%w: %s", ErrMigrationFailed, s.Migration.Error)
+		}
+	case MigrationStatusSyncing:
+		// The databases may not be listed yet when the migration starts syncing
+		p.Done = p.Total > 0 && p.Completed == p.Total
+	}
+
+	return p
+}
+
+// WatchMigration polls the migration status and sends a progress snapshot on every poll.
+// The channel is closed once the migration is done, an error occurs or the context is done;
+// errors, including a failed migration, are sent as the last snapshot.
+func (h ServiceTaskHandler) WatchMigration(ctx context.Context, project, service string, interval time.Duration) <-chan MigrationProgress {
+	ch := make(chan MigrationProgress)
+
+	go func() {
+		defer close(ch)
+
+		send := func(p MigrationProgress) bool {
+			select {
+			case ch <- p:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		err := waitFor(ctx, interval, func() (bool, error) {
+			s, err := h.MigrationStatus(ctx, project, service)
+			if err != nil {
+				return false, err
+			}
+
+			p := newMigrationProgress(s)
+			if !send(p) {
+				return false, ctx.Err()
+			}
+
+			return p.Done, nil
+		})
+
+		if err != nil {
+			send(MigrationProgress{Err: err})
+		}
+	}()
+
+	return ch
+}
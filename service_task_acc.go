@@ -30,8 +30,9 @@ type (
 
 	// ServiceTaskRequest represents service task request
 	ServiceTaskRequest struct {
-		TargetVersion string `json:"target_version"`
-		TaskType      string `json:"task_type"`
+		TargetVersion  string                 `json:"target_version,omitempty"`
+		TaskType       string                 `json:"task_type"`
+		MigrationCheck *MigrationCheckRequest `json:"migration_check,omitempty"`
 	}
 )
 
@@ -0,0 +1,183 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	ServiceTaskTypeUpgradeCheck   = "upgrade_check"
+	ServiceTaskTypeMigrationCheck = "migration_check"
+)
+
+var (
+	// ErrServiceTaskCheckFailed is returned when a check task completes unsuccessfully.
+	ErrServiceTaskCheckFailed = errors.New("service task check failed")
+	// ErrServiceUpgradeVersionKeyRequired is returned when an upgrade has no version user config key to set.
+	ErrServiceUpgradeVersionKeyRequired = errors.New("service upgrade requires a version user config key")
+)
+
+type (
+	// ServiceTaskCheckResult is the structured outcome of a finished check task.
+	ServiceTaskCheckResult struct {
+		Task     ServiceTask
+		Passed   bool
+		Messages []string
+	}
+
+	// ServiceUpgradeRequest describes a major version upgrade.
+	ServiceUpgradeRequest struct {
+		// TargetVersion is the major version to upgrade to, e.g. "16".
+		TargetVersion string
+		// VersionUserConfigKey is the user config key set once the check passes, e.g. "pg_version".
+		// It is required unless CheckOnly is set.
+		VersionUserConfigKey string
+		// CheckOnly runs the check task without changing the service version.
+		CheckOnly bool
+		// PollInterval defaults to DefaultPollInterval.
+		PollInterval time.Duration
+	}
+
+	// ServiceUpgradeResult is the outcome of an upgrade workflow.
+	ServiceUpgradeResult struct {
+		Check   *ServiceTaskCheckResult
+		Service *Service
+	}
+)
+
+// newServiceTaskCheckResult splits the task result text into messages.
+func newServiceTaskCheckResult(task ServiceTask) *ServiceTaskCheckResult {
+	r := &ServiceTaskCheckResult{
+		Task:   task,
+		Passed: task.Success != nil && *task.Success,
+	}
+
+	for _, line := range strings.Split(task.Result, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			r.Messages = append(r.Messages, line)
+		}
+	}
+
+	return r
+}
+
+// Wait polls a service task until it completes.
+func (h ServiceTaskHandler) Wait(ctx context.Context, project, service, id string, interval time.Duration) (*ServiceTask, error) {
+	var task *ServiceTask
+	err := waitFor(ctx, interval, func() (bool, error) {
+		rsp, err := h.Get(ctx, project, service, id)
+		if err != nil {
+			return false, err
+		}
+
+		task = &rsp.Task
+		return task.Success != nil, nil
+	})
+
+	return task, err
+}
+
+// RunCheck creates a check task, waits for it to complete and returns its structured result.
+// A failed check is reported through ServiceTaskCheckResult.Passed, not as an error.
+func (h ServiceTaskHandler) RunCheck(ctx context.Context, project, service string, req ServiceTaskRequest, interval time.Duration) (*ServiceTaskCheckResult, error) {
+	rsp, err := h.Create(ctx, project, service, req)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &rsp.Task
+	if task.Success == nil {
+		task, err = h.Wait(ctx, project, service, rsp.Task.Id, interval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newServiceTaskCheckResult(*task), nil
+}
+
+// Upgrade runs an upgrade check task and, once it passes, sets the version user config
+// and waits for the service to be RUNNING again.
+func (h *ServicesHandler) Upgrade(ctx context.Context, project, service string, req ServiceUpgradeRequest) (*ServiceUpgradeResult, error) {
+	if req.VersionUserConfigKey == "" && !req.CheckOnly {
+		return nil, ErrServiceUpgradeVersionKeyRequired
+	}
+
+	check, err := h.client.ServiceTask.RunCheck(ctx, project, service, ServiceTaskRequest{
+		TargetVersion: req.TargetVersion,
+		TaskType:      ServiceTaskTypeUpgradeCheck,
+	}, req.PollInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ServiceUpgradeResult{Check: check}
+	if !check.Passed {
+		return result, fmt.Errorf("%w: %s", ErrServiceTaskCheckFailed, strings.Join(check.Messages, "; "))
+	}
+
+	if req.CheckOnly {
+		return result, nil
+	}
+
+	_, err = h.UpdateUserConfig(ctx, project, service, map[string]interface{}{
+		req.VersionUserConfigKey: req.TargetVersion,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// The service may still report RUNNING right after the update,
+	// so wait for every node to be running as well.
+	err = waitFor(ctx, req.PollInterval, func() (bool, error) {
+		s, err := h.Get(ctx, project, service)
+		if err != nil {
+			return false, err
+		}
+
+		result.Service = s
+		return s.State == ServiceStateRunning && nodesRunning(s), nil
+	})
+
+	return result, err
+}
+
+func nodesRunning(s *Service) bool {
+	for _, n := range s.NodeStates {
+		if n.State != "running" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// UpgradePostgres upgrades a PostgreSQL service to the given major version.
+func (h *ServicesHandler) UpgradePostgres(ctx context.Context, project, service, targetVersion string) (*ServiceUpgradeResult, error) {
+	return h.Upgrade(ctx, project, service, ServiceUpgradeRequest{
+		TargetVersion:        targetVersion,
+		VersionUserConfigKey: "pg_version",
+	})
+}
+
+// CheckMySQLMigration runs a migration check of a MySQL service against its source database
+// and waits for its result, a check which does not pass fails with ErrServiceTaskCheckFailed.
+func (h *ServicesHandler) CheckMySQLMigration(ctx context.Context, project, service string, req MigrationCheckRequest, interval time.Duration) (*ServiceTaskCheckResult, error) {
+	if req.IgnoreRoles != "" {
+		return nil, errors.New("ignore roles is only supported by PostgreSQL migrations")
+	}
+
+	check, err := h.client.ServiceTask.MigrationCheck(ctx, project, service, req, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	if !check.Passed {
+		return check, fmt.Errorf("%w: %s", ErrServiceTaskCheckFailed, strings.Join(check.Messages, "; "))
+	}
+
+	return check, nil
+}
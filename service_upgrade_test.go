@@ -0,0 +1,131 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func setupServiceUpgradeTestCase(t *testing.T, success bool) (*Client, *map[string]interface{}, func(t *testing.T)) {
+	t.Log("setup Service Upgrade test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+	)
+
+	var updated map[string]interface{}
+	taskPolls := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		var rsp interface{}
+		switch {
+		case r.URL.Path == "/userauth":
+			rsp = authResponse{Token: AccessToken, State: "active"}
+		case r.URL.Path == "/project/test-pr/service/test-sr/task" && r.Method == http.MethodPost:
+			rsp = ServiceTaskResponse{Task: ServiceTask{Id: "task-1", TaskType: "upgrade_check"}}
+		case r.URL.Path == "/project/test-pr/service/test-sr/task/task-1":
+			taskPolls++
+			task := ServiceTask{Id: "task-1", TaskType: "upgrade_check"}
+			if taskPolls > 1 {
+				task.Success = &success
+				task.Result = "check one\n\ncheck two\n"
+			}
+			rsp = ServiceTaskResponse{Task: task}
+		case r.URL.Path == "/project/test-pr/service/test-sr" && r.Method == http.MethodPut:
+			var req UpdateServiceRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+			updated = req.UserConfig
+			rsp = ServiceResponse{Service: &Service{Name: "test-sr", State: ServiceStateRebuilding}}
+		case r.URL.Path == "/project/test-pr/service/test-sr":
+			rsp = ServiceResponse{Service: &Service{
+				Name:       "test-sr",
+				State:      ServiceStateRunning,
+				UserConfig: map[string]interface{}{"pg_version": "15", "ip_filter": []string{"0.0.0.0/0"}},
+				NodeStates: []*NodeState{{Name: "test-sr-1", State: "running"}},
+			}}
+		}
+
+		if err := json.NewEncoder(w).Encode(rsp); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	return c, &updated, func(t *testing.T) {
+		t.Log("teardown Service Upgrade test case")
+		ts.Close()
+	}
+}
+
+func TestServicesHandler_Upgrade(t *testing.T) {
+	tests := []struct {
+		name    string
+		success bool
+		wantErr error
+	}{
+		{name: "passed", success: true},
+		{name: "failed", success: false, wantErr: ErrServiceTaskCheckFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, updated, tearDown := setupServiceUpgradeTestCase(t, tt.success)
+			defer tearDown(t)
+
+			got, err := c.Services.Upgrade(context.Background(), "test-pr", "test-sr", ServiceUpgradeRequest{
+				TargetVersion:        "16",
+				VersionUserConfigKey: "pg_version",
+				PollInterval:         time.Millisecond,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Upgrade() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got.Check.Passed != tt.success || len(got.Check.Messages) != 2 {
+				t.Errorf("Upgrade() check = %+v", got.Check)
+			}
+
+			if !tt.success {
+				if *updated != nil {
+					t.Errorf("Upgrade() updated the service after a failed check")
+				}
+				return
+			}
+
+			if (*updated)["pg_version"] != "16" || (*updated)["ip_filter"] == nil {
+				t.Errorf("Upgrade() user config = %v", *updated)
+			}
+			if got.Service == nil || got.Service.State != ServiceStateRunning {
+				t.Errorf("Upgrade() service = %+v", got.Service)
+			}
+		})
+	}
+}
+
+func TestServicesHandler_UpgradeWithoutVersionKey(t *testing.T) {
+	c, updated, tearDown := setupServiceUpgradeTestCase(t, true)
+	defer tearDown(t)
+
+	_, err := c.Services.Upgrade(context.Background(), "test-pr", "test-sr", ServiceUpgradeRequest{TargetVersion: "16"})
+	if !errors.Is(err, ErrServiceUpgradeVersionKeyRequired) {
+		t.Fatalf("Upgrade() error = %v, want %v", err, ErrServiceUpgradeVersionKeyRequired)
+	}
+	if *updated != nil {
+		t.Errorf("Upgrade() updated the service without a version user config key")
+	}
+}
@@ -0,0 +1,197 @@
+package aiven
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	StaticIPStateCreating  = "creating"
+	StaticIPStateCreated   = "created"
+	StaticIPStateAvailable = "available"
+	StaticIPStateAssigned  = "assigned"
+	StaticIPStateDeleting  = "deleting"
+	StaticIPStateDeleted   = "deleted"
+)
+
+// isDangling reports whether the static ip is allocated but associated with no service.
+func (ip StaticIP) isDangling() bool {
+	return ip.ServiceName == "" && ip.State == StaticIPStateCreated
+}
+
+// inPool reports whether the static ip is associated with the service and not being released.
+func (ip StaticIP) inPool(service string) bool {
+	return ip.ServiceName == service && ip.State != StaticIPStateDeleting && ip.State != StaticIPStateDeleted
+}
+
+// waitForStaticIPs polls the project static ips until every given ID is in one of the states.
+func (h *StaticIPsHandler) waitForStaticIPs(ctx context.Context, project string, ids []string, interval time.Duration, states ...string) ([]StaticIP, error) {
+	var ready []StaticIP
+	err := waitFor(ctx, interval, func() (bool, error) {
+		rsp, err := h.List(ctx, project)
+		if err != nil {
+			return false, err
+		}
+
+		byID := make(map[string]StaticIP, len(rsp.StaticIPs))
+		for _, ip := range rsp.StaticIPs {
+			byID[ip.StaticIPAddressID] = ip
+		}
+
+		ready = ready[:0]
+		for _, id := range ids {
+			ip, ok := byID[id]
+			if !ok {
+				return false, Error{Message: fmt.Sprintf("static ip not found by id:%s", id), Status: 404}
+			}
+
+			for _, s := range states {
+				if ip.State == s {
+					ready = append(ready, ip)
+					break
+				}
+			}
+		}
+
+		return len(ready) == len(ids), nil
+	})
+
+	return ready, err
+}
+
+// EnsurePool makes sure count static ips of the given cloud are associated with the service
+// and enables the static_ips user config. Dangling static ips of the cloud are reused
+// before allocating new ones. Returns the static ips of the pool.
+func (h *StaticIPsHandler) EnsurePool(ctx context.Context, project, service, cloud string, count int, interval time.Duration) ([]StaticIP, error) {
+	rsp, err := h.List(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	var pool []string
+	var free []string
+	for _, ip := range rsp.StaticIPs {
+		if ip.CloudName != cloud {
+			continue
+		}
+
+		switch {
+		case ip.inPool(service):
+			pool = append(pool, ip.StaticIPAddressID)
+		case ip.isDangling():
+			free = append(free, ip.StaticIPAddressID)
+		}
+	}
+
+	if len(pool) > count {
+		return nil, fmt.Errorf("service %s already has %d static ips in %s, more than %d", service, len(pool), cloud, count)
+	}
+
+	var missing []string
+	for len(pool)+len(missing) < count {
+		if len(free) > 0 {
+			missing, free = append(missing, free[0]), free[1:]
+			continue
+		}
+
+		ip, err := h.Create(ctx, project, CreateStaticIPRequest{CloudName: cloud})
+		if err != nil {
+			return nil, err
+		}
+		missing = append(missing, ip.StaticIPAddressID)
+	}
+
+	if len(missing) > 0 {
+		if _, err = h.waitForStaticIPs(ctx, project, missing, interval, StaticIPStateCreated); err != nil {
+			return nil, err
+		}
+
+		for _, id := range missing {
+			if err := h.Associate(ctx, project, id, AssociateStaticIPRequest{ServiceName: service}); err != nil {
+				return nil, err
+			}
+		}
+		pool = append(pool, missing...)
+	}
+
+	// Associated static ips are available until the service uses them
+	if _, err = h.waitForStaticIPs(ctx, project, pool, interval, StaticIPStateAvailable, StaticIPStateAssigned); err != nil {
+		return nil, err
+	}
+
+	if _, err = h.client.Services.UpdateUserConfig(ctx, project, service, map[string]interface{}{"static_ips": true}); err != nil {
+		return nil, err
+	}
+
+	return h.waitForStaticIPs(ctx, project, pool, interval, StaticIPStateAssigned)
+}
+
+// TeardownPool disables the static_ips user config of the service, dissociates
+// its static ips and releases them. Returns the released static ips.
+func (h *StaticIPsHandler) TeardownPool(ctx context.Context, project, service string, interval time.Duration) ([]StaticIP, error) {
+	if _, err := h.client.Services.UpdateUserConfig(ctx, project, service, map[string]interface{}{"static_ips": false}); err != nil {
+		return nil, err
+	}
+
+	rsp, err := h.List(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, ip := range rsp.StaticIPs {
+		if ip.inPool(service) {
+			ids = append(ids, ip.StaticIPAddressID)
+		}
+	}
+
+	// Static ips can be dissociated once the service stopped using them
+	if _, err = h.waitForStaticIPs(ctx, project, ids, interval, StaticIPStateAvailable); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if err := h.Dissociate(ctx, project, id); err != nil {
+			return nil, err
+		}
+	}
+
+	released, err := h.waitForStaticIPs(ctx, project, ids, interval, StaticIPStateCreated)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if err := h.Delete(ctx, project, DeleteStaticIPRequest{StaticIPAddressID: id}); err != nil {
+			return nil, err
+		}
+	}
+
+	return released, nil
+}
+
+// GarbageCollect releases the static ips of the project which are not associated with any service.
+// With dryRun the dangling static ips are only returned.
+func (h *StaticIPsHandler) GarbageCollect(ctx context.Context, project string, dryRun bool) ([]StaticIP, error) {
+	rsp, err := h.List(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	var dangling []StaticIP
+	for _, ip := range rsp.StaticIPs {
+		if !ip.isDangling() {
+			continue
+		}
+
+		if !dryRun {
+			if err := h.Delete(ctx, project, DeleteStaticIPRequest{StaticIPAddressID: ip.StaticIPAddressID}); err != nil {
+				return dangling, err
+			}
+		}
+		dangling = append(dangling, ip)
+	}
+
+	return dangling, nil
+}
@@ -0,0 +1,217 @@
+package aiven
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticIPPoolTestServer mocks the static ips of a project, the static_ips user config of
+// the services moves their associated static ips between available and assigned.
+type staticIPPoolTestServer struct {
+	mu      sync.Mutex
+	ips     map[string]*StaticIP
+	created int
+	deleted []string
+	// state is the state of the service, powered records the powered field of its updates.
+	state   string
+	powered []bool
+}
+
+func (s *staticIPPoolTestServer) list() ListStaticIPResponse {
+	var rsp ListStaticIPResponse
+	for _, ip := range s.ips {
+		rsp.StaticIPs = append(rsp.StaticIPs, *ip)
+	}
+	sort.Slice(rsp.StaticIPs, func(i, j int) bool {
+		return rsp.StaticIPs[i].StaticIPAddressID < rsp.StaticIPs[j].StaticIPAddressID
+	})
+
+	return rsp
+}
+
+func setupStaticIPPoolTestCase(t *testing.T, ips ...StaticIP) (*Client, *staticIPPoolTestServer, func(t *testing.T)) {
+	t.Log("setup Static IP Pool test case")
+
+	const (
+		UserName     = "test@aiven.io"
+		UserPassword = "testabcd"
+		AccessToken  = "some-random-token"
+	)
+
+	s := &staticIPPoolTestServer{ips: make(map[string]*StaticIP, len(ips)), state: ServiceStateRunning}
+	for i := range ips {
+		s.ips[ips[i].StaticIPAddressID] = &ips[i]
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		const staticIPsPath = "/project/test-pr/static-ips"
+
+		var rsp interface{} = struct{}{}
+		switch {
+		case r.URL.Path == "/userauth":
+			rsp = authResponse{Token: AccessToken, State: "active"}
+		case r.URL.Path == "/project/test-pr/service/test-sr" && r.Method == http.MethodPut:
+			var req UpdateServiceRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+			s.powered = append(s.powered, req.Powered)
+
+			from, to := StaticIPStateAssigned, StaticIPStateAvailable
+			if req.UserConfig["static_ips"] == true {
+				from, to = to, from
+			}
+			for _, ip := range s.ips {
+				if ip.ServiceName == "test-sr" && ip.State == from {
+					ip.State = to
+				}
+			}
+			rsp = ServiceResponse{Service: &Service{Name: "test-sr", UserConfig: req.UserConfig}}
+		case r.URL.Path == "/project/test-pr/service/test-sr":
+			rsp = ServiceResponse{Service: &Service{Name: "test-sr", State: s.state, UserConfig: map[string]interface{}{}}}
+		case r.URL.Path == staticIPsPath && r.Method == http.MethodPost:
+			var req CreateStaticIPRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+
+			s.created++
+			ip := &StaticIP{StaticIPAddressID: "ip-new-" + string(rune('0'+s.created)), CloudName: req.CloudName, State: StaticIPStateCreated}
+			s.ips[ip.StaticIPAddressID] = ip
+			rsp = CreateStaticIPResponse{StaticIP: *ip}
+		case r.URL.Path == staticIPsPath:
+			rsp = s.list()
+		case strings.HasSuffix(r.URL.Path, "/association"):
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, staticIPsPath+"/"), "/association")
+			ip := s.ips[id]
+			if r.Method == http.MethodPost {
+				var req AssociateStaticIPRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Error(err)
+				}
+				ip.ServiceName, ip.State = req.ServiceName, StaticIPStateAvailable
+			} else {
+				ip.ServiceName, ip.State = "", StaticIPStateCreated
+			}
+		case strings.HasPrefix(r.URL.Path, staticIPsPath+"/") && r.Method == http.MethodDelete:
+			id := strings.TrimPrefix(r.URL.Path, staticIPsPath+"/")
+			s.ips[id].State = StaticIPStateDeleted
+			s.deleted = append(s.deleted, id)
+		}
+
+		if err := json.NewEncoder(w).Encode(rsp); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	apiUrl = ts.URL
+	c, err := NewUserClient(UserName, UserPassword, "aiven-go-client-test/"+Version())
+	if err != nil {
+		t.Fatalf("user authentication error: %s", err)
+	}
+
+	return c, s, func(t *testing.T) {
+		t.Log("teardown Static IP Pool test case")
+		ts.Close()
+	}
+}
+
+func TestStaticIPsHandler_EnsurePool(t *testing.T) {
+	c, s, tearDown := setupStaticIPPoolTestCase(t,
+		StaticIP{StaticIPAddressID: "ip-1", CloudName: "aws-eu-west-1", ServiceName: "test-sr", State: StaticIPStateAssigned},
+		StaticIP{StaticIPAddressID: "ip-2", CloudName: "aws-eu-west-1", ServiceName: "test-sr", State: StaticIPStateDeleting},
+		StaticIP{StaticIPAddressID: "ip-3", CloudName: "aws-eu-west-1", State: StaticIPStateCreated},
+		StaticIP{StaticIPAddressID: "ip-4", CloudName: "google-europe-west1", State: StaticIPStateCreated},
+	)
+	defer tearDown(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got, err := c.StaticIPs.EnsurePool(ctx, "test-pr", "test-sr", "aws-eu-west-1", 3, time.Millisecond)
+	require.NoError(t, err)
+
+	ids := make([]string, len(got))
+	for i, ip := range got {
+		ids[i] = ip.StaticIPAddressID
+		assert.Equal(t, StaticIPStateAssigned, ip.State)
+	}
+	// The dangling ip of the cloud is reused before creating a new one
+	assert.Equal(t, []string{"ip-1", "ip-3", "ip-new-1"}, ids)
+	assert.Equal(t, 1, s.created)
+	assert.Equal(t, StaticIPStateCreated, s.ips["ip-4"].State)
+	assert.Equal(t, []bool{true}, s.powered)
+}
+
+func TestStaticIPsHandler_TeardownPool(t *testing.T) {
+	c, s, tearDown := setupStaticIPPoolTestCase(t,
+		StaticIP{StaticIPAddressID: "ip-1", CloudName: "aws-eu-west-1", ServiceName: "test-sr", State: StaticIPStateAssigned},
+		StaticIP{StaticIPAddressID: "ip-2", CloudName: "aws-eu-west-1", ServiceName: "test-sr", State: StaticIPStateDeleting},
+		StaticIP{StaticIPAddressID: "ip-3", CloudName: "aws-eu-west-1", ServiceName: "test-sr", State: StaticIPStateDeleted},
+		StaticIP{StaticIPAddressID: "ip-4", CloudName: "aws-eu-west-1", ServiceName: "other-sr", State: StaticIPStateAssigned},
+	)
+	defer tearDown(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Static ips being released are skipped, waiting for them to become available would never end
+	got, err := c.StaticIPs.TeardownPool(ctx, "test-pr", "test-sr", time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "ip-1", got[0].StaticIPAddressID)
+	assert.Equal(t, []string{"ip-1"}, s.deleted)
+	assert.Equal(t, StaticIPStateAssigned, s.ips["ip-4"].State)
+}
+
+func TestStaticIPsHandler_TeardownPoolPoweredOff(t *testing.T) {
+	c, s, tearDown := setupStaticIPPoolTestCase(t,
+		StaticIP{StaticIPAddressID: "ip-1", CloudName: "aws-eu-west-1", ServiceName: "test-sr", State: StaticIPStateAssigned},
+	)
+	defer tearDown(t)
+	s.state = ServiceStatePowerOff
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Changing the static ips of a powered off service must not power it on
+	_, err := c.StaticIPs.TeardownPool(ctx, "test-pr", "test-sr", time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false}, s.powered)
+}
+
+func TestStaticIPsHandler_GarbageCollect(t *testing.T) {
+	c, s, tearDown := setupStaticIPPoolTestCase(t,
+		StaticIP{StaticIPAddressID: "ip-1", CloudName: "aws-eu-west-1", ServiceName: "test-sr", State: StaticIPStateAssigned},
+		StaticIP{StaticIPAddressID: "ip-2", CloudName: "aws-eu-west-1", State: StaticIPStateCreated},
+		StaticIP{StaticIPAddressID: "ip-3", CloudName: "aws-eu-west-1", State: StaticIPStateCreating},
+	)
+	defer tearDown(t)
+
+	ctx := context.Background()
+	got, err := c.StaticIPs.GarbageCollect(ctx, "test-pr", true)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "ip-2", got[0].StaticIPAddressID)
+	assert.Empty(t, s.deleted)
+
+	_, err = c.StaticIPs.GarbageCollect(ctx, "test-pr", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ip-2"}, s.deleted)
+}
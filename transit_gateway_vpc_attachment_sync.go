@@ -0,0 +1,165 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// ErrTransitGatewayVPCAttachmentDeleteAll is returned when the desired CIDRs are empty
+// and the caller did not allow deleting every user peer network CIDR of the attachment.
+var ErrTransitGatewayVPCAttachmentDeleteAll = errors.New("no desired CIDRs, refusing to delete every route of the attachment")
+
+type (
+	// TransitGatewayVPCAttachmentSyncRequest holds the desired user peer network CIDRs of a transit gateway attachment.
+	TransitGatewayVPCAttachmentSyncRequest struct {
+		PeerCloudAccount  string
+		PeerVPC           string
+		PeerResourceGroup *string
+		CIDRs             []string
+		// AllowDeleteAll allows an empty CIDRs to delete every live CIDR, which cuts the traffic
+		// through the attachment. Without it, an empty CIDRs fails with ErrTransitGatewayVPCAttachmentDeleteAll.
+		AllowDeleteAll bool
+	}
+
+	// TransitGatewayVPCAttachmentPlan is the minimal change bringing the attachment to the desired CIDRs.
+	TransitGatewayVPCAttachmentPlan struct {
+		Add    []string
+		Delete []string
+	}
+)
+
+// Empty reports whether the attachment is already in sync.
+func (p *TransitGatewayVPCAttachmentPlan) Empty() bool {
+	return len(p.Add) == 0 && len(p.Delete) == 0
+}
+
+// request builds the update request, the API requires both lists to be present.
+func (p *TransitGatewayVPCAttachmentPlan) request(req TransitGatewayVPCAttachmentSyncRequest) TransitGatewayVPCAttachmentRequest {
+	r := TransitGatewayVPCAttachmentRequest{
+		Add:    make([]TransitGatewayVPCAttachment, 0, len(p.Add)),
+		Delete: append(make([]string, 0, len(p.Delete)), p.Delete...),
+	}
+
+	for _, cidr := range p.Add {
+		r.Add = append(r.Add, TransitGatewayVPCAttachment{
+			CIDR:              cidr,
+			PeerCloudAccount:  req.PeerCloudAccount,
+			PeerResourceGroup: req.PeerResourceGroup,
+			PeerVPC:           req.PeerVPC,
+		})
+	}
+
+	return r
+}
+
+// planTransitGatewayVPCAttachment validates the desired CIDRs against each other and the project VPC network,
+// then diffs them against the live ones. CIDRs are compared by their network, not their spelling.
+// An empty desired list is rejected unless allowDeleteAll is set.
+func planTransitGatewayVPCAttachment(vpcCIDR string, live, desired []string, allowDeleteAll bool) (*TransitGatewayVPCAttachmentPlan, error) {
+	if len(desired) == 0 && !allowDeleteAll {
+		return nil, ErrTransitGatewayVPCAttachmentDeleteAll
+	}
+
+	vpcNetwork, err := netip.ParsePrefix(vpcCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project VPC network CIDR %q: %w", vpcCIDR, err)
+	}
+
+	want := make(map[netip.Prefix]bool, len(desired))
+	wanted := make([]netip.Prefix, 0, len(desired))
+	for _, cidr := range desired {
+		p, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		if p != p.Masked() {
+			return nil, fmt.Errorf("invalid CIDR %q: host bits are set, did you mean %s", cidr, p.Masked())
+		}
+		if p.Overlaps(vpcNetwork) {
+			return nil, fmt.Errorf("CIDR %s overlaps the project VPC network %s", p, vpcNetwork)
+		}
+		if want[p] {
+			continue
+		}
+
+		for _, w := range wanted {
+			if w.Overlaps(p) {
+				return nil, fmt.Errorf("CIDR %s overlaps %s", p, w)
+			}
+		}
+
+		want[p] = true
+		wanted = append(wanted, p)
+	}
+
+	plan := new(TransitGatewayVPCAttachmentPlan)
+	have := make(map[netip.Prefix]bool, len(live))
+	for _, cidr := range live {
+		p, err := netip.ParsePrefix(cidr)
+		if err == nil {
+			p = p.Masked()
+			have[p] = true
+		}
+
+		// Unparsable live CIDRs are not wanted either, delete them as they are
+		if err != nil || !want[p] {
+			plan.Delete = append(plan.Delete, cidr)
+		}
+	}
+
+	for _, p := range wanted {
+		if !have[p] {
+			plan.Add = append(plan.Add, p.String())
+		}
+	}
+
+	return plan, nil
+}
+
+// Plan returns the changes Sync would apply without applying them.
+func (h *TransitGatewayVPCAttachmentHandler) Plan(
+	ctx context.Context,
+	project, projectVPCId string,
+	req TransitGatewayVPCAttachmentSyncRequest,
+) (*TransitGatewayVPCAttachmentPlan, error) {
+	vpc, err := h.client.VPCs.Get(ctx, project, projectVPCId)
+	if err != nil {
+		return nil, err
+	}
+
+	var live []string
+	found := false
+	for _, pc := range vpc.PeeringConnections {
+		if pc.PeerCloudAccount == req.PeerCloudAccount &&
+			pc.PeerVPC == req.PeerVPC &&
+			(req.PeerResourceGroup == nil || eqStrPointers(pc.PeerResourceGroup, req.PeerResourceGroup)) {
+			live = pc.UserPeerNetworkCIDRs
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, Error{Message: "Peering connection not found", Status: 404}
+	}
+
+	return planTransitGatewayVPCAttachment(vpc.NetworkCIDR, live, req.CIDRs, req.AllowDeleteAll)
+}
+
+// Sync brings the user peer network CIDRs of the attachment to the desired list with a single minimal update.
+// The returned VPC is nil when nothing had to change.
+func (h *TransitGatewayVPCAttachmentHandler) Sync(
+	ctx context.Context,
+	project, projectVPCId string,
+	req TransitGatewayVPCAttachmentSyncRequest,
+) (*TransitGatewayVPCAttachmentPlan, *VPC, error) {
+	plan, err := h.Plan(ctx, project, projectVPCId, req)
+	if err != nil || plan.Empty() {
+		return plan, nil, err
+	}
+
+	vpc, err := h.Update(ctx, project, projectVPCId, plan.request(req))
+	return plan, vpc, err
+}
@@ -232,3 +232,73 @@ func TestTransitGatewayVPCAttachmentHandler_Update(t *testing.T) {
 		})
 	}
 }
+
+func TestPlanTransitGatewayVPCAttachment(t *testing.T) {
+	tests := []struct {
+		name       string
+		live       []string
+		desired    []string
+		allowAll   bool
+		wantAdd    []string
+		wantDelete []string
+		wantErr    bool
+	}{
+		{
+			name:       "diff",
+			live:       []string{"10.0.0.0/24", "10.1.0.0/24"},
+			desired:    []string{"10.1.0.0/24", "10.2.0.0/24", "10.2.0.0/24"},
+			wantAdd:    []string{"10.2.0.0/24"},
+			wantDelete: []string{"10.0.0.0/24"},
+		},
+		{
+			name:    "in sync",
+			live:    []string{"10.0.0.0/24"},
+			desired: []string{"10.0.0.0/24"},
+		},
+		{
+			name:    "delete all",
+			live:    []string{"10.0.0.0/24"},
+			wantErr: true,
+		},
+		{
+			name:       "delete all allowed",
+			live:       []string{"10.0.0.0/24"},
+			allowAll:   true,
+			wantDelete: []string{"10.0.0.0/24"},
+		},
+		{
+			name:    "invalid",
+			desired: []string{"10.0.0/24"},
+			wantErr: true,
+		},
+		{
+			name:    "host bits",
+			desired: []string{"10.0.0.1/24"},
+			wantErr: true,
+		},
+		{
+			name:    "overlaps vpc",
+			desired: []string{"172.16.0.0/16"},
+			wantErr: true,
+		},
+		{
+			name:    "overlaps desired",
+			desired: []string{"10.0.0.0/16", "10.0.1.0/24"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := planTransitGatewayVPCAttachment("172.16.0.0/24", tt.live, tt.desired, tt.allowAll)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("planTransitGatewayVPCAttachment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Add, tt.wantAdd) || !reflect.DeepEqual(got.Delete, tt.wantDelete) {
+				t.Errorf("planTransitGatewayVPCAttachment() got = %+v, want add %v delete %v", got, tt.wantAdd, tt.wantDelete)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package aiven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+const (
+	// VPCNetworkKindVPC is a project VPC network.
+	VPCNetworkKindVPC = "vpc"
+	// VPCNetworkKindPeer is a user peer network CIDR of a peering connection or a transit gateway attachment.
+	VPCNetworkKindPeer = "peer"
+)
+
+var (
+	// ErrNoFreeCIDR is returned when the supernet has no free CIDR of the requested size.
+	ErrNoFreeCIDR = errors.New("no free CIDR left in the supernet")
+)
+
+type (
+	// VPCNetwork is a CIDR known to the planner along with where it comes from.
+	VPCNetwork struct {
+		Project      string
+		ProjectVPCID string
+		CloudName    string
+		Kind         string
+		// PeerVPC is the peered VPC for peer networks.
+		PeerVPC string
+		CIDR    netip.Prefix
+	}
+
+	// VPCNetworkOverlap represents two overlapping networks. It is a Conflict when both networks
+	// are routed through the same project VPC, otherwise it only matters once they are peered.
+	VPCNetworkOverlap struct {
+		A        VPCNetwork
+		B        VPCNetwork
+		Conflict bool
+	}
+
+	// VPCCIDRPlanner detects overlaps between the known networks and proposes free CIDRs.
+	VPCCIDRPlanner struct {
+		Networks []VPCNetwork
+		// Invalid lists the CIDRs which could not be parsed.
+		Invalid []error
+	}
+)
+
+// NewVPCCIDRPlanner creates an empty planner, add networks with AddVPC.
+func NewVPCCIDRPlanner() *VPCCIDRPlanner {
+	return &VPCCIDRPlanner{}
+}
+
+// AddVPC adds a project VPC and the user peer network CIDRs of its peering connections,
+// the VPC must include its peering connections as returned by VPCsHandler.Get.
+func (p *VPCCIDRPlanner) AddVPC(project string, vpc *VPC) {
+	p.add(VPCNetwork{
+		Project:      project,
+		ProjectVPCID: vpc.ProjectVPCID,
+		CloudName:    vpc.CloudName,
+		Kind:         VPCNetworkKindVPC,
+	}, vpc.NetworkCIDR)
+
+	for _, pc := range vpc.PeeringConnections {
+		for _, cidr := range pc.UserPeerNetworkCIDRs {
+			p.add(VPCNetwork{
+				Project:      project,
+				ProjectVPCID: vpc.ProjectVPCID,
+				CloudName:    vpc.CloudName,
+				Kind:         VPCNetworkKindPeer,
+				PeerVPC:      pc.PeerVPC,
+			}, cidr)
+		}
+	}
+}
+
+func (p *VPCCIDRPlanner) add(n VPCNetwork, cidr string) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		p.Invalid = append(p.Invalid, fmt.Errorf("project %s VPC %s: %w", n.Project, n.ProjectVPCID, err))
+		return
+	}
+
+	n.CIDR = prefix.Masked()
+	p.Networks = append(p.Networks, n)
+}
+
+// LoadVPCCIDRPlanner loads every VPC and peering connection of the given projects into a planner.
+// The VPCs are retrieved concurrently and added in the order they are listed.
+func (h *VPCsHandler) LoadVPCCIDRPlanner(ctx context.Context, projects ...string) (*VPCCIDRPlanner, error) {
+	type projectVPC struct {
+		project string
+		id      string
+	}
+
+	var refs []projectVPC
+	for _, project := range projects {
+		vpcs, err := h.List(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range vpcs {
+			refs = append(refs, projectVPC{project: project, id: v.ProjectVPCID})
+		}
+	}
+
+	// List does not guarantee peering connections are included
+	vpcs := make([]*VPC, len(refs))
+	err := forEachConcurrently(ctx, len(refs), defaultInventoryConcurrency, func(ctx context.Context, i int) error {
+		vpc, err := h.Get(ctx, refs[i].project, refs[i].id)
+		if err != nil {
+			return err
+		}
+
+		vpcs[i] = vpc
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewVPCCIDRPlanner()
+	for i, vpc := range vpcs {
+		p.AddVPC(refs[i].project, vpc)
+	}
+
+	return p, nil
+}
+
+// Overlaps returns every pair of overlapping networks.
+func (p *VPCCIDRPlanner) Overlaps() []VPCNetworkOverlap {
+	var overlaps []VPCNetworkOverlap
+	for i, a := range p.Networks {
+		for _, b := range p.Networks[i+1:] {
+			if !a.CIDR.Overlaps(b.CIDR) {
+				continue
+			}
+
+			sameVPC := a.Project == b.Project && a.ProjectVPCID == b.ProjectVPCID
+			overlaps = append(overlaps, VPCNetworkOverlap{
+				A:        a,
+				B:        b,
+				Conflict: sameVPC,
+			})
+		}
+	}
+
+	return overlaps
+}
+
+// Conflicts returns the overlaps which break routing of a project VPC.
+func (p *VPCCIDRPlanner) Conflicts() []VPCNetworkOverlap {
+	var conflicts []VPCNetworkOverlap
+	for _, o := range p.Overlaps() {
+		if o.Conflict {
+			conflicts = append(conflicts, o)
+		}
+	}
+
+	return conflicts
+}
+
+// Propose returns the first CIDR of the given prefix length within the supernet
+// which overlaps none of the known networks.
+func (p *VPCCIDRPlanner) Propose(supernet string, bits int) (netip.Prefix, error) {
+	used := make([]netip.Prefix, 0, len(p.Networks))
+	for _, n := range p.Networks {
+		used = append(used, n.CIDR)
+	}
+
+	return nextFreeCIDR(supernet, bits, used)
+}
+
+// ProposePerCloud proposes a CIDR for each cloud region, the proposals do not overlap each other.
+func (p *VPCCIDRPlanner) ProposePerCloud(supernet string, bits int, clouds ...string) (map[string]netip.Prefix, error) {
+	used := make([]netip.Prefix, 0, len(p.Networks)+len(clouds))
+	for _, n := range p.Networks {
+		used = append(used, n.CIDR)
+	}
+
+	proposals := make(map[string]netip.Prefix, len(clouds))
+	for _, cloud := range clouds {
+		cidr, err := nextFreeCIDR(supernet, bits, used)
+		if err != nil {
+			return nil, fmt.Errorf("cloud %s: %w", cloud, err)
+		}
+
+		proposals[cloud] = cidr
+		used = append(used, cidr)
+	}
+
+	return proposals, nil
+}
+
+// nextFreeCIDR walks the supernet in steps of the requested size, jumping past the end
+// of the used range a candidate overlaps.
+func nextFreeCIDR(supernet string, bits int, used []netip.Prefix) (netip.Prefix, error) {
+	super, err := netip.ParsePrefix(supernet)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	super = super.Masked()
+
+	if bits < super.Bits() || bits > super.Addr().BitLen() {
+		return netip.Prefix{}, fmt.Errorf("prefix length /%d does not fit in %s", bits, super)
+	}
+
+	candidate := netip.PrefixFrom(super.Addr(), bits)
+	for super.Contains(candidate.Addr()) {
+		var overlap *netip.Prefix
+		for i, u := range used {
+			if u.Overlaps(candidate) {
+				overlap = &used[i]
+				break
+			}
+		}
+
+		if overlap == nil {
+			return candidate, nil
+		}
+
+		// Both prefixes are aligned, so the larger one ends where the candidate has to resume
+		skip := candidate
+		if overlap.Bits() < bits {
+			skip = *overlap
+		}
+
+		next, ok := nextPrefix(skip)
+		if !ok {
+			break
+		}
+		candidate = netip.PrefixFrom(next.Addr(), bits)
+	}
+
+	return netip.Prefix{}, fmt.Errorf("%w: /%d in %s", ErrNoFreeCIDR, bits, super)
+}
+
+// nextPrefix returns the prefix of the same size right after the given one.
+func nextPrefix(p netip.Prefix) (netip.Prefix, bool) {
+	b := p.Addr().AsSlice()
+	bit := p.Bits() - 1
+	for bit >= 0 {
+		mask := byte(1) << (7 - bit%8)
+		if b[bit/8]&mask == 0 {
+			b[bit/8] |= mask
+			addr, _ := netip.AddrFromSlice(b)
+			return netip.PrefixFrom(addr, p.Bits()), true
+		}
+		b[bit/8] &^= mask
+		bit--
+	}
+
+	return netip.Prefix{}, false
+}
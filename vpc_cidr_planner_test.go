@@ -0,0 +1,68 @@
+package aiven
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestVPCCIDRPlanner(t *testing.T) {
+	p := NewVPCCIDRPlanner()
+	p.AddVPC("pr1", &VPC{
+		ProjectVPCID: "vpc1",
+		CloudName:    "aws-eu-west-1",
+		NetworkCIDR:  "10.0.0.0/24",
+		PeeringConnections: []*VPCPeeringConnection{
+			{PeerVPC: "tgw", UserPeerNetworkCIDRs: []string{"10.0.0.128/25", "10.1.0.0/16", "bogus"}},
+		},
+	})
+	p.AddVPC("pr2", &VPC{ProjectVPCID: "vpc2", CloudName: "aws-eu-west-1", NetworkCIDR: "10.1.2.0/24"})
+
+	if len(p.Invalid) != 1 {
+		t.Errorf("Invalid got = %v", p.Invalid)
+	}
+
+	overlaps := p.Overlaps()
+	if len(overlaps) != 2 {
+		t.Fatalf("Overlaps() got = %+v", overlaps)
+	}
+
+	conflicts := p.Conflicts()
+	if len(conflicts) != 1 || conflicts[0].A.Kind != VPCNetworkKindVPC || conflicts[0].B.CIDR.String() != "10.0.0.128/25" {
+		t.Errorf("Conflicts() got = %+v", conflicts)
+	}
+
+	got, err := p.Propose("10.0.0.0/8", 24)
+	if err != nil || got.String() != "10.0.1.0/24" {
+		t.Errorf("Propose() got = %v, %v", got, err)
+	}
+
+	perCloud, err := p.ProposePerCloud("10.0.0.0/15", 16, "aws-eu-west-1", "google-europe-west1")
+	if !errors.Is(err, ErrNoFreeCIDR) {
+		t.Errorf("ProposePerCloud() got = %v, %v", perCloud, err)
+	}
+
+	perCloud, err = p.ProposePerCloud("10.0.0.0/14", 16, "aws-eu-west-1", "google-europe-west1")
+	if err != nil || perCloud["aws-eu-west-1"].String() != "10.2.0.0/16" || perCloud["google-europe-west1"].String() != "10.3.0.0/16" {
+		t.Errorf("ProposePerCloud() got = %v, %v", perCloud, err)
+	}
+}
+
+func Test_nextFreeCIDR(t *testing.T) {
+	used := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/9"),
+		netip.MustParsePrefix("10.128.0.0/28"),
+		netip.MustParsePrefix("10.128.0.20/30"),
+	}
+
+	// The used ranges are jumped over instead of walked in /28 steps
+	got, err := nextFreeCIDR("10.0.0.0/8", 28, used)
+	if err != nil || got.String() != "10.128.0.32/28" {
+		t.Errorf("nextFreeCIDR() got = %v, %v", got, err)
+	}
+
+	got, err = nextFreeCIDR("255.255.255.0/24", 28, []netip.Prefix{netip.MustParsePrefix("255.255.255.0/24")})
+	if !errors.Is(err, ErrNoFreeCIDR) {
+		t.Errorf("nextFreeCIDR() got = %v, %v", got, err)
+	}
+}
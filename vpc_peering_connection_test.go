@@ -52,3 +52,80 @@ func TestEqStrPointers(t *testing.T) {
 		})
 	}
 }
+
+func TestDiagnoseVPCPeering(t *testing.T) {
+	tests := []struct {
+		name        string
+		cloudName   string
+		conn        *VPCPeeringConnection
+		wantCloud   string
+		wantHealthy bool
+		wantAction  string
+	}{
+		{
+			name:      "aws pending peer",
+			cloudName: "aws-eu-west-1",
+			conn: &VPCPeeringConnection{
+				State:            VPCPeeringStatePendingPeer,
+				PeerCloudAccount: "123456789012",
+				PeerVPC:          "vpc-1",
+				StateInfo: &map[string]interface{}{
+					"aws_vpc_peering_connection_id": "pcx-1",
+					"message":                       "Pending peer",
+				},
+			},
+			wantCloud:  VPCPeeringCloudAWS,
+			wantAction: "accept the VPC peering connection pcx-1 in AWS account 123456789012",
+		},
+		{
+			name: "gcp pending peer",
+			conn: &VPCPeeringConnection{
+				State:            VPCPeeringStatePendingPeer,
+				PeerCloudAccount: "my-project",
+				PeerVPC:          "my-network",
+				StateInfo: &map[string]interface{}{
+					"to_project_id":  "aiven-project",
+					"to_vpc_network": "aiven-network",
+				},
+			},
+			wantCloud:  VPCPeeringCloudGoogle,
+			wantAction: "create the reverse peering from network my-network in project my-project to network aiven-network in project aiven-project",
+		},
+		{
+			name: "azure pending peer",
+			conn: &VPCPeeringConnection{
+				State:             VPCPeeringStatePendingPeer,
+				PeerAzureAppId:    "app-1",
+				PeerAzureTenantId: "tenant-1",
+				PeerResourceGroup: ref("rg-1"),
+				PeerVPC:           "vnet-1",
+			},
+			wantCloud:  VPCPeeringCloudAzure,
+			wantAction: "grant the service principal of application app-1 in tenant tenant-1",
+		},
+		{
+			name:        "active",
+			cloudName:   "google-europe-west1",
+			conn:        &VPCPeeringConnection{State: VPCPeeringStateActive},
+			wantCloud:   VPCPeeringCloudGoogle,
+			wantHealthy: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiagnoseVPCPeering(tt.cloudName, tt.conn)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCloud, got.Cloud)
+			assert.Equal(t, tt.wantHealthy, got.Healthy)
+			if tt.wantAction == "" {
+				assert.Empty(t, got.Actions)
+			} else {
+				assert.NotEmpty(t, got.Actions)
+				assert.Contains(t, got.Actions[0], tt.wantAction)
+			}
+		})
+	}
+
+	assert.True(t, VPCPeeringCanTransition(VPCPeeringStatePendingPeer, VPCPeeringStateActive))
+	assert.False(t, VPCPeeringCanTransition(VPCPeeringStateDeleted, VPCPeeringStateActive))
+}
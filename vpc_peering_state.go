@@ -0,0 +1,273 @@
+package aiven
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	VPCPeeringStateApproved             = "APPROVED"
+	VPCPeeringStatePendingPeer          = "PENDING_PEER"
+	VPCPeeringStateActive               = "ACTIVE"
+	VPCPeeringStateDeleting             = "DELETING"
+	VPCPeeringStateDeleted              = "DELETED"
+	VPCPeeringStateDeletedByPeer        = "DELETED_BY_PEER"
+	VPCPeeringStateRejectedByPeer       = "REJECTED_BY_PEER"
+	VPCPeeringStateInvalidSpecification = "INVALID_SPECIFICATION"
+
+	VPCPeeringCloudAWS    = "aws"
+	VPCPeeringCloudGoogle = "google"
+	VPCPeeringCloudAzure  = "azure"
+)
+
+// VPCPeeringStateTransitions is the VPC peering connection state machine:
+// the states a peering connection may move to from each state.
+var VPCPeeringStateTransitions = map[string][]string{
+	VPCPeeringStateApproved: {
+		VPCPeeringStatePendingPeer,
+		VPCPeeringStateActive,
+		VPCPeeringStateInvalidSpecification,
+		VPCPeeringStateDeleting,
+	},
+	VPCPeeringStatePendingPeer: {
+		VPCPeeringStateActive,
+		VPCPeeringStateRejectedByPeer,
+		VPCPeeringStateInvalidSpecification,
+		VPCPeeringStateDeletedByPeer,
+		VPCPeeringStateDeleting,
+	},
+	VPCPeeringStateActive: {
+		VPCPeeringStateDeletedByPeer,
+		VPCPeeringStateDeleting,
+	},
+	VPCPeeringStateInvalidSpecification: {VPCPeeringStateDeleting},
+	VPCPeeringStateRejectedByPeer:       {VPCPeeringStateDeleting},
+	VPCPeeringStateDeletedByPeer:        {VPCPeeringStateDeleting},
+	VPCPeeringStateDeleting:             {VPCPeeringStateDeleted},
+	VPCPeeringStateDeleted:              {},
+}
+
+var reAWSAccountID = regexp.MustCompile(`^\d{12}$`)
+
+type (
+	// VPCPeeringStateWarning represents a warning reported in a peering connection state info
+	VPCPeeringStateWarning struct {
+		ConflictingAWSAccountID              string `json:"conflicting_aws_account_id,omitempty"`
+		ConflictingAWSVPCPeeringConnectionID string `json:"conflicting_aws_vpc_peering_connection_id,omitempty"`
+		ConflictingVPCID                     string `json:"conflicting_vpc_id,omitempty"`
+		Message                              string `json:"message"`
+		Type                                 string `json:"type"`
+	}
+
+	// VPCPeeringStateInfo holds the state info fields shared by every cloud
+	VPCPeeringStateInfo struct {
+		Message  string                   `json:"message"`
+		Type     string                   `json:"type"`
+		Warnings []VPCPeeringStateWarning `json:"warnings"`
+	}
+
+	// AWSVPCPeeringStateInfo represents the state info of an AWS peering connection
+	AWSVPCPeeringStateInfo struct {
+		VPCPeeringStateInfo
+		AWSVPCPeeringConnectionID string `json:"aws_vpc_peering_connection_id"`
+	}
+
+	// GCPVPCPeeringStateInfo represents the state info of a Google Cloud peering connection
+	GCPVPCPeeringStateInfo struct {
+		VPCPeeringStateInfo
+		ToProjectID  string `json:"to_project_id"`
+		ToVPCNetwork string `json:"to_vpc_network"`
+	}
+
+	// AzureVPCPeeringStateInfo represents the state info of an Azure peering connection
+	AzureVPCPeeringStateInfo struct {
+		VPCPeeringStateInfo
+		ToTenantID         string `json:"to_tenant_id"`
+		ToSubscriptionID   string `json:"to_subscription_id"`
+		ToResourceGroup    string `json:"to_resource_group"`
+		ToVirtualNetwork   string `json:"to_virtual_network"`
+		PeerAzureAppID     string `json:"-"`
+		PeerAzureTenantID  string `json:"-"`
+		PeerResourceGroup  string `json:"-"`
+		PeerVirtualNetwork string `json:"-"`
+	}
+
+	// VPCPeeringDiagnosis explains the state of a peering connection and what is left to do.
+	VPCPeeringDiagnosis struct {
+		Cloud    string
+		State    string
+		Healthy  bool
+		Terminal bool
+		Summary  string
+		Actions  []string
+		Warnings []string
+	}
+)
+
+// VPCPeeringCanTransition reports whether a peering connection may move from one state to another.
+func VPCPeeringCanTransition(from, to string) bool {
+	for _, s := range VPCPeeringStateTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeStateInfo decodes the untyped state info into the given struct.
+func (c *VPCPeeringConnection) decodeStateInfo(v interface{}) error {
+	if c.StateInfo == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(*c.StateInfo)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// AWSStateInfo returns the state info of an AWS peering connection.
+func (c *VPCPeeringConnection) AWSStateInfo() (*AWSVPCPeeringStateInfo, error) {
+	var info AWSVPCPeeringStateInfo
+	return &info, c.decodeStateInfo(&info)
+}
+
+// GCPStateInfo returns the state info of a Google Cloud peering connection.
+func (c *VPCPeeringConnection) GCPStateInfo() (*GCPVPCPeeringStateInfo, error) {
+	var info GCPVPCPeeringStateInfo
+	return &info, c.decodeStateInfo(&info)
+}
+
+// AzureStateInfo returns the state info of an Azure peering connection,
+// completed with the peer application and tenant of the connection.
+func (c *VPCPeeringConnection) AzureStateInfo() (*AzureVPCPeeringStateInfo, error) {
+	var info AzureVPCPeeringStateInfo
+	if err := c.decodeStateInfo(&info); err != nil {
+		return nil, err
+	}
+
+	info.PeerAzureAppID = c.PeerAzureAppId
+	info.PeerAzureTenantID = c.PeerAzureTenantId
+	info.PeerResourceGroup = PointerToString(c.PeerResourceGroup)
+	info.PeerVirtualNetwork = c.PeerVPC
+	return &info, nil
+}
+
+// vpcPeeringCloud guesses the cloud of a peering connection when the project VPC cloud is unknown.
+func vpcPeeringCloud(cloudName string, c *VPCPeeringConnection) string {
+	switch {
+	case strings.HasPrefix(cloudName, "aws-"):
+		return VPCPeeringCloudAWS
+	case strings.HasPrefix(cloudName, "google-"):
+		return VPCPeeringCloudGoogle
+	case strings.HasPrefix(cloudName, "azure-"):
+		return VPCPeeringCloudAzure
+	case c.PeerAzureAppId != "" || c.PeerResourceGroup != nil:
+		return VPCPeeringCloudAzure
+	case reAWSAccountID.MatchString(c.PeerCloudAccount):
+		return VPCPeeringCloudAWS
+	}
+
+	return VPCPeeringCloudGoogle
+}
+
+// DiagnoseVPCPeering explains the state of a peering connection of a project VPC
+// in the given cloud, e.g. "aws-eu-west-1", and lists what is left to do on the peer side.
+// cloudName may be empty, the cloud is then guessed from the peering connection.
+func DiagnoseVPCPeering(cloudName string, c *VPCPeeringConnection) (*VPCPeeringDiagnosis, error) {
+	next, known := VPCPeeringStateTransitions[c.State]
+	d := &VPCPeeringDiagnosis{
+		Cloud:    vpcPeeringCloud(cloudName, c),
+		State:    c.State,
+		Terminal: known && len(next) == 0,
+	}
+
+	var common VPCPeeringStateInfo
+	if err := c.decodeStateInfo(&common); err != nil {
+		return nil, err
+	}
+
+	for _, w := range common.Warnings {
+		d.Warnings = append(d.Warnings, w.Message)
+	}
+
+	switch c.State {
+	case VPCPeeringStateApproved:
+		d.Summary = "Aiven is setting up the peering connection"
+	case VPCPeeringStateActive:
+		d.Healthy = true
+		d.Summary = "the peering connection is active"
+	case VPCPeeringStatePendingPeer:
+		d.Summary = "the peering connection is waiting for the peer side"
+		actions, err := pendingPeerActions(d.Cloud, c)
+		if err != nil {
+			return nil, err
+		}
+		d.Actions = actions
+	case VPCPeeringStateInvalidSpecification:
+		d.Summary = "the peering connection specification is invalid: " + common.Message
+		d.Actions = []string{"fix the peer account, VPC or region and recreate the peering connection"}
+	case VPCPeeringStateRejectedByPeer:
+		d.Summary = "the peer rejected the peering connection"
+		d.Actions = []string{"delete the peering connection and create a new one once the peer is ready to accept it"}
+	case VPCPeeringStateDeletedByPeer:
+		d.Summary = "the peer deleted the peering connection"
+		d.Actions = []string{"delete the peering connection and create a new one to restore connectivity"}
+	case VPCPeeringStateDeleting:
+		d.Summary = "the peering connection is being deleted"
+	case VPCPeeringStateDeleted:
+		d.Summary = "the peering connection is deleted"
+	default:
+		d.Summary = fmt.Sprintf("unknown peering connection state %s", c.State)
+	}
+
+	return d, nil
+}
+
+func pendingPeerActions(cloud string, c *VPCPeeringConnection) ([]string, error) {
+	switch cloud {
+	case VPCPeeringCloudAWS:
+		info, err := c.AWSStateInfo()
+		if err != nil {
+			return nil, err
+		}
+
+		region := PointerToString(c.PeerRegion)
+		if region == "" {
+			region = "the project VPC region"
+		}
+		return []string{
+			fmt.Sprintf("accept the VPC peering connection %s in AWS account %s, %s", info.AWSVPCPeeringConnectionID, c.PeerCloudAccount, region),
+			fmt.Sprintf("add routes to the project VPC network through %s in the route tables of %s", info.AWSVPCPeeringConnectionID, c.PeerVPC),
+		}, nil
+	case VPCPeeringCloudGoogle:
+		info, err := c.GCPStateInfo()
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{
+			fmt.Sprintf("create the reverse peering from network %s in project %s to network %s in project %s",
+				c.PeerVPC, c.PeerCloudAccount, info.ToVPCNetwork, info.ToProjectID),
+		}, nil
+	case VPCPeeringCloudAzure:
+		info, err := c.AzureStateInfo()
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{
+			fmt.Sprintf("grant the service principal of application %s in tenant %s the Network Contributor role on virtual network %s in resource group %s",
+				info.PeerAzureAppID, info.PeerAzureTenantID, info.PeerVirtualNetwork, info.PeerResourceGroup),
+			fmt.Sprintf("create the peering from virtual network %s to %s in subscription %s, tenant %s",
+				info.PeerVirtualNetwork, info.ToVirtualNetwork, info.ToSubscriptionID, info.ToTenantID),
+		}, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,32 @@
+package aiven
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultPollInterval is the interval used by workflows waiting for a resource to change its state.
+const DefaultPollInterval = 10 * time.Second
+
+// waitFor calls check every interval until it reports done, returns an error or the context is done.
+func waitFor(ctx context.Context, interval time.Duration, check func() (bool, error)) error {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}